@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"pr-reviewer/internal/core"
+)
+
+type AccountRepository struct {
+	db *DB
+}
+
+func NewAccountRepository(database *DB) *AccountRepository {
+	return &AccountRepository{db: database}
+}
+
+func (r *AccountRepository) Link(ctx context.Context, account core.ExternalAccount) error {
+	_, err := r.db.querier(ctx).ExecContext(ctx, `
+		INSERT INTO external_accounts (provider, login, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id
+	`, account.Provider, account.Login, account.UserID)
+	return err
+}
+
+func (r *AccountRepository) ResolveUserID(ctx context.Context, provider, login string) (string, error) {
+	var userID string
+	err := r.db.querier(ctx).GetContext(ctx, &userID,
+		"SELECT user_id FROM external_accounts WHERE provider = $1 AND login = $2", provider, login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", core.ErrNotFound
+	}
+	return userID, err
+}
+
+func (r *AccountRepository) LoginsByUserIDs(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	result := make(map[string]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		UserID string `db:"user_id"`
+		Login  string `db:"login"`
+	}
+	// у пользователя может быть несколько логинов, берём первый по алфавиту
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT DISTINCT ON (user_id) user_id, login
+		FROM external_accounts
+		WHERE provider = $1 AND user_id = ANY($2)
+		ORDER BY user_id, login
+	`, provider, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.UserID] = row.Login
+	}
+	return result, nil
+}
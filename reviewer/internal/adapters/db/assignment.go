@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type AssignmentRepository struct {
+	db *DB
+}
+
+func NewAssignmentRepository(database *DB) *AssignmentRepository {
+	return &AssignmentRepository{db: database}
+}
+
+func (r *AssignmentRepository) Append(ctx context.Context, assignments ...core.Assignment) error {
+	q := r.db.querier(ctx)
+	for _, assignment := range assignments {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO reviewer_assignments (
+				pull_request_id, reviewer_id, assigned_at, reason, replaced_reviewer_id, actor_id
+			)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, assignment.PullRequestID, assignment.ReviewerID, assignment.AssignedAt.UTC(), string(assignment.Reason),
+			nullString(assignment.ReplacedReviewerID), nullString(assignment.ActorID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AssignmentRepository) Close(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	_, err := r.db.querier(ctx).ExecContext(ctx, `
+		UPDATE reviewer_assignments
+		SET unassigned_at = GREATEST(assigned_at, $3)
+		WHERE pull_request_id = $1 AND reviewer_id = $2 AND unassigned_at IS NULL
+	`, prID, reviewerID, at.UTC())
+	return err
+}
+
+func (r *AssignmentRepository) GetByPullRequestID(ctx context.Context, prID string) ([]core.Assignment, error) {
+	var rows []assignmentRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT id, pull_request_id, reviewer_id, assigned_at, unassigned_at, reason, replaced_reviewer_id, actor_id
+		FROM reviewer_assignments
+		WHERE pull_request_id = $1
+		ORDER BY assigned_at, id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.Assignment, len(rows))
+	for i, row := range rows {
+		result[i] = row.toCoreAssignment()
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+
+	"pr-reviewer/internal/core"
+)
+
+type AuditRepository struct {
+	db *DB
+}
+
+func NewAuditRepository(database *DB) *AuditRepository {
+	return &AuditRepository{db: database}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, record *core.AuditRecord) error {
+	return r.db.querier(ctx).QueryRowxContext(ctx, `
+		INSERT INTO audit_log (actor_id, token_id, token_name, role, operation, entity_id, payload, result, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, nullString(record.ActorID), nullInt64(record.TokenID), nullString(record.TokenName), nullString(string(record.Role)),
+		string(record.Operation), record.EntityID,
+		[]byte(record.Payload), []byte(record.Result), record.CreatedAt.UTC()).Scan(&record.ID)
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter core.AuditFilter) ([]core.AuditRecord, error) {
+	var rows []auditRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT id, actor_id, token_id, token_name, role, operation, entity_id, payload, result, created_at
+		FROM audit_log
+		WHERE ($1::text = '' OR actor_id = $1::text)
+			AND ($2::text = '' OR operation = $2::text)
+			AND ($3::text = '' OR entity_id = $3::text)
+			AND ($4::timestamp IS NULL OR created_at >= $4::timestamp)
+			AND ($5::timestamp IS NULL OR created_at < $5::timestamp)
+			AND ($6::bigint = 0 OR id < $6::bigint)
+		ORDER BY id DESC
+		LIMIT $7
+	`, filter.ActorID, string(filter.Operation), filter.EntityID,
+		utcTime(filter.From), utcTime(filter.To), filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.AuditRecord, len(rows))
+	for i, row := range rows {
+		result[i] = row.toCoreRecord()
+	}
+	return result, nil
+}
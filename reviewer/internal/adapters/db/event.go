@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"pr-reviewer/internal/core"
+)
+
+type EventRepository struct {
+	db *DB
+}
+
+func NewEventRepository(database *DB) *EventRepository {
+	return &EventRepository{db: database}
+}
+
+func (r *EventRepository) Append(ctx context.Context, event core.Event) error {
+	payload, err := json.Marshal(eventPayloadFromCore(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.querier(ctx).ExecContext(ctx, `
+		INSERT INTO outbox_events (event_type, pull_request_id, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, string(event.Type), event.PullRequest.ID, payload, event.OccurredAt.UTC())
+	return err
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type IdempotencyRepository struct {
+	db *DB
+}
+
+func NewIdempotencyRepository(database *DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: database}
+}
+
+func (r *IdempotencyRepository) Create(ctx context.Context, record *core.IdempotencyRecord) error {
+	return r.db.withTx(ctx, func(tx querier) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", record.CreatedAt.UTC())
+		if err != nil {
+			return err
+		}
+
+		// параллельный запрос с тем же ключом ждёт коммита первого и получает конфликт
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (scope, idempotency_key) DO NOTHING
+		`, record.Scope, record.Key, record.Fingerprint, record.CreatedAt.UTC(), record.ExpiresAt.UTC())
+		if err != nil {
+			return err
+		}
+
+		created, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if created == 0 {
+			return core.ErrIdempotencyKeyExists
+		}
+		return nil
+	})
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, scope, key string) (*core.IdempotencyRecord, error) {
+	var row idempotencyRow
+	err := r.db.querier(ctx).GetContext(ctx, &row, `
+		SELECT scope, idempotency_key, fingerprint, response_status, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2
+	`, scope, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := row.toCoreRecord()
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, response core.IdempotentResponse, expiresAt time.Time) error {
+	result, err := r.db.querier(ctx).ExecContext(ctx, `
+		UPDATE idempotency_keys SET response_status = $3, response_body = $4, expires_at = $5
+		WHERE scope = $1 AND idempotency_key = $2
+	`, scope, key, response.StatusCode, response.Body, expiresAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, scope, key string) error {
+	_, err := r.db.querier(ctx).ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2", scope, key)
+	return err
+}
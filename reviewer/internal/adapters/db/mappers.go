@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"pr-reviewer/internal/core"
@@ -23,21 +24,499 @@ func (r *userRow) toCoreUser() *core.User {
 	}
 }
 
+type unavailabilityRow struct {
+	StartsAt time.Time `db:"starts_at"`
+	EndsAt   time.Time `db:"ends_at"`
+}
+
+func (r *unavailabilityRow) toCoreUnavailability() core.Unavailability {
+	return core.Unavailability{From: r.StartsAt, To: r.EndsAt}
+}
+
+type teamRow struct {
+	Name              string         `db:"name"`
+	ReviewerStrategy  sql.NullString `db:"reviewer_strategy"`
+	RequiredReviewers int            `db:"required_reviewers"`
+	RequiredApprovals int            `db:"required_approvals"`
+	AutoReassign      bool           `db:"auto_reassign"`
+	ReviewSLASeconds  int64          `db:"review_sla_seconds"`
+	CodeOwners        []byte         `db:"code_owners"`
+}
+
+func (r *teamRow) toCoreSettings() core.TeamSettings {
+	return core.TeamSettings{
+		ReviewerStrategy:  core.SelectionStrategy(r.ReviewerStrategy.String),
+		RequiredReviewers: r.RequiredReviewers,
+		RequiredApprovals: r.RequiredApprovals,
+		AutoReassign:      r.AutoReassign,
+		ReviewSLA:         time.Duration(r.ReviewSLASeconds) * time.Second,
+	}
+}
+
+// ownershipRuleJSON - формат хранения правила владения кодом в teams.code_owners.
+type ownershipRuleJSON struct {
+	Pattern string   `json:"pattern"`
+	Users   []string `json:"users,omitempty"`
+	Teams   []string `json:"teams,omitempty"`
+}
+
+func (r *teamRow) toCoreOwnershipRules() ([]core.OwnershipRule, error) {
+	var stored []ownershipRuleJSON
+	if err := json.Unmarshal(r.CodeOwners, &stored); err != nil {
+		return nil, err
+	}
+
+	rules := make([]core.OwnershipRule, len(stored))
+	for i, rule := range stored {
+		rules[i] = core.OwnershipRule{Pattern: rule.Pattern, Users: rule.Users, Teams: rule.Teams}
+	}
+	return rules, nil
+}
+
+func ownershipRulesFromCore(rules []core.OwnershipRule) []ownershipRuleJSON {
+	result := make([]ownershipRuleJSON, len(rules))
+	for i, rule := range rules {
+		result[i] = ownershipRuleJSON{Pattern: rule.Pattern, Users: rule.Users, Teams: rule.Teams}
+	}
+	return result
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullInt64(value int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: value, Valid: value != 0}
+}
+
 type prRow struct {
-	ID        string       `db:"id"`
-	Name      string       `db:"name"`
-	AuthorID  string       `db:"author_id"`
-	Status    string       `db:"status"`
-	CreatedAt time.Time    `db:"created_at"`
-	MergedAt  sql.NullTime `db:"merged_at"`
+	ID                string       `db:"id"`
+	Name              string       `db:"name"`
+	AuthorID          string       `db:"author_id"`
+	Status            string       `db:"status"`
+	RequiredReviewers int          `db:"required_reviewers"`
+	CreatedAt         time.Time    `db:"created_at"`
+	MergedAt          sql.NullTime `db:"merged_at"`
+
+	MergeOverrideActor  sql.NullString `db:"merge_override_actor"`
+	MergeOverrideReason sql.NullString `db:"merge_override_reason"`
+}
+
+func (r *prRow) toCorePullRequest(reviews []reviewRow) *core.PullRequest {
+	pr := &core.PullRequest{
+		ID:                r.ID,
+		Name:              r.Name,
+		AuthorID:          r.AuthorID,
+		Status:            core.PullRequestStatus(r.Status),
+		ReviewersIDs:      make([]string, len(reviews)),
+		Reviews:           make([]core.Review, len(reviews)),
+		RequiredReviewers: r.RequiredReviewers,
+		CreatedAt:         r.CreatedAt,
+	}
+	if r.MergedAt.Valid {
+		mergedAt := r.MergedAt.Time
+		pr.MergedAt = &mergedAt
+	}
+	for i, review := range reviews {
+		pr.ReviewersIDs[i] = review.ReviewerID
+		pr.Reviews[i] = review.toCoreReview()
+	}
+	if r.MergeOverrideActor.Valid {
+		pr.MergeOverride = &core.MergeOverride{
+			ActorID: r.MergeOverrideActor.String,
+			Reason:  r.MergeOverrideReason.String,
+		}
+	}
+	return pr
+}
+
+type reviewRow struct {
+	PullRequestID string    `db:"pull_request_id"`
+	ReviewerID    string    `db:"reviewer_id"`
+	State         string    `db:"state"`
+	AssignedAt    time.Time `db:"assigned_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+
+	FallbackTeam sql.NullString `db:"fallback_team"`
+	OwnerPattern sql.NullString `db:"owner_pattern"`
+	EscalatedAt  sql.NullTime   `db:"escalated_at"`
+}
+
+func (r *reviewRow) toCoreReview() core.Review {
+	review := core.Review{
+		ReviewerID:   r.ReviewerID,
+		State:        core.ReviewState(r.State),
+		AssignedAt:   r.AssignedAt,
+		UpdatedAt:    r.UpdatedAt,
+		FallbackTeam: r.FallbackTeam.String,
+		OwnerPattern: r.OwnerPattern.String,
+	}
+	if r.EscalatedAt.Valid {
+		escalatedAt := r.EscalatedAt.Time
+		review.EscalatedAt = &escalatedAt
+	}
+	return review
+}
+
+func nullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: value.UTC(), Valid: true}
+}
+
+type assignmentRow struct {
+	ID                 int64          `db:"id"`
+	PullRequestID      string         `db:"pull_request_id"`
+	ReviewerID         string         `db:"reviewer_id"`
+	AssignedAt         time.Time      `db:"assigned_at"`
+	UnassignedAt       sql.NullTime   `db:"unassigned_at"`
+	Reason             string         `db:"reason"`
+	ReplacedReviewerID sql.NullString `db:"replaced_reviewer_id"`
+	ActorID            sql.NullString `db:"actor_id"`
+}
+
+func (r *assignmentRow) toCoreAssignment() core.Assignment {
+	assignment := core.Assignment{
+		ID:                 r.ID,
+		PullRequestID:      r.PullRequestID,
+		ReviewerID:         r.ReviewerID,
+		AssignedAt:         r.AssignedAt,
+		Reason:             core.AssignmentReason(r.Reason),
+		ReplacedReviewerID: r.ReplacedReviewerID.String,
+		ActorID:            r.ActorID.String,
+	}
+	if r.UnassignedAt.Valid {
+		unassignedAt := r.UnassignedAt.Time
+		assignment.UnassignedAt = &unassignedAt
+	}
+	return assignment
+}
+
+// eventPayload - формат хранения события в outbox_events.payload.
+type eventPayload struct {
+	PullRequest  prPayload            `json:"pull_request"`
+	Reassignment *reassignmentPayload `json:"reassignment,omitempty"`
+	Escalation   *escalationPayload   `json:"escalation,omitempty"`
+}
+
+type prPayload struct {
+	ID                string          `json:"id"`
+	Name              string          `json:"name"`
+	AuthorID          string          `json:"author_id"`
+	Status            string          `json:"status"`
+	ReviewersIDs      []string        `json:"reviewer_ids"`
+	Reviews           []reviewPayload `json:"reviews,omitempty"`
+	RequiredReviewers int             `json:"required_reviewers"`
+	CreatedAt         time.Time       `json:"created_at"`
+	MergedAt          *time.Time      `json:"merged_at,omitempty"`
+}
+
+type reviewPayload struct {
+	ReviewerID string    `json:"reviewer_id"`
+	State      string    `json:"state"`
+	AssignedAt time.Time `json:"assigned_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	FallbackTeam string     `json:"fallback_team,omitempty"`
+	OwnerPattern string     `json:"owner_pattern,omitempty"`
+	EscalatedAt  *time.Time `json:"escalated_at,omitempty"`
+}
+
+type reassignmentPayload struct {
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+type escalationPayload struct {
+	ReviewerID string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+	DueAt      time.Time `json:"due_at"`
+}
+
+func eventPayloadFromCore(event core.Event) eventPayload {
+	pr := event.PullRequest
+	payload := eventPayload{
+		PullRequest: prPayload{
+			ID:                pr.ID,
+			Name:              pr.Name,
+			AuthorID:          pr.AuthorID,
+			Status:            string(pr.Status),
+			ReviewersIDs:      pr.ReviewersIDs,
+			RequiredReviewers: pr.RequiredReviewers,
+			CreatedAt:         pr.CreatedAt,
+			MergedAt:          pr.MergedAt,
+		},
+	}
+	for _, review := range pr.Reviews {
+		payload.PullRequest.Reviews = append(payload.PullRequest.Reviews, reviewPayload{
+			ReviewerID:   review.ReviewerID,
+			State:        string(review.State),
+			AssignedAt:   review.AssignedAt,
+			UpdatedAt:    review.UpdatedAt,
+			FallbackTeam: review.FallbackTeam,
+			OwnerPattern: review.OwnerPattern,
+			EscalatedAt:  review.EscalatedAt,
+		})
+	}
+	if event.Reassignment != nil {
+		payload.Reassignment = &reassignmentPayload{
+			OldReviewerID: event.Reassignment.OldReviewerID,
+			NewReviewerID: event.Reassignment.NewReviewerID,
+		}
+	}
+	if event.Escalation != nil {
+		payload.Escalation = &escalationPayload{
+			ReviewerID: event.Escalation.ReviewerID,
+			AssignedAt: event.Escalation.AssignedAt,
+			DueAt:      event.Escalation.DueAt,
+		}
+	}
+	return payload
+}
+
+func (p *eventPayload) toCoreEvent(id int64, eventType string, occurredAt time.Time) core.Event {
+	event := core.Event{
+		ID:   id,
+		Type: core.EventType(eventType),
+		PullRequest: core.PullRequest{
+			ID:                p.PullRequest.ID,
+			Name:              p.PullRequest.Name,
+			AuthorID:          p.PullRequest.AuthorID,
+			Status:            core.PullRequestStatus(p.PullRequest.Status),
+			ReviewersIDs:      p.PullRequest.ReviewersIDs,
+			RequiredReviewers: p.PullRequest.RequiredReviewers,
+			CreatedAt:         p.PullRequest.CreatedAt,
+			MergedAt:          p.PullRequest.MergedAt,
+		},
+		OccurredAt: occurredAt,
+	}
+	for _, review := range p.PullRequest.Reviews {
+		event.PullRequest.Reviews = append(event.PullRequest.Reviews, core.Review{
+			ReviewerID:   review.ReviewerID,
+			State:        core.ReviewState(review.State),
+			AssignedAt:   review.AssignedAt,
+			UpdatedAt:    review.UpdatedAt,
+			FallbackTeam: review.FallbackTeam,
+			OwnerPattern: review.OwnerPattern,
+			EscalatedAt:  review.EscalatedAt,
+		})
+	}
+	if p.Escalation != nil {
+		event.Escalation = &core.Escalation{
+			ReviewerID: p.Escalation.ReviewerID,
+			AssignedAt: p.Escalation.AssignedAt,
+			DueAt:      p.Escalation.DueAt,
+		}
+	}
+	if p.Reassignment != nil {
+		event.Reassignment = &core.Reassignment{
+			PullRequestID: p.PullRequest.ID,
+			OldReviewerID: p.Reassignment.OldReviewerID,
+			NewReviewerID: p.Reassignment.NewReviewerID,
+		}
+	}
+	return event
+}
+
+type subscriptionRow struct {
+	ID         int64     `db:"id"`
+	URL        string    `db:"url"`
+	Secret     string    `db:"secret"`
+	EventTypes []byte    `db:"event_types"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (r *subscriptionRow) toCoreSubscription() (core.WebhookSubscription, error) {
+	var eventTypes []string
+	if err := json.Unmarshal(r.EventTypes, &eventTypes); err != nil {
+		return core.WebhookSubscription{}, err
+	}
+
+	result := core.WebhookSubscription{
+		ID:         r.ID,
+		URL:        r.URL,
+		Secret:     r.Secret,
+		EventTypes: make([]core.EventType, len(eventTypes)),
+		CreatedAt:  r.CreatedAt,
+	}
+	for i, eventType := range eventTypes {
+		result.EventTypes[i] = core.EventType(eventType)
+	}
+	return result, nil
+}
+
+func eventTypesToStrings(eventTypes []core.EventType) []string {
+	result := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		result[i] = string(eventType)
+	}
+	return result
 }
 
-func (r *prRow) toCorePullRequest(reviewerIDs []string) *core.PullRequest {
-	return &core.PullRequest{
+type deliveryRow struct {
+	ID       int64 `db:"id"`
+	Attempts int   `db:"attempts"`
+
+	EventID        int64     `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	Payload        []byte    `db:"payload"`
+	EventCreatedAt time.Time `db:"event_created_at"`
+
+	SubscriptionID        int64     `db:"subscription_id"`
+	URL                   string    `db:"url"`
+	Secret                string    `db:"secret"`
+	EventTypes            []byte    `db:"event_types"`
+	SubscriptionCreatedAt time.Time `db:"subscription_created_at"`
+}
+
+func (r *deliveryRow) toCoreDelivery() (core.WebhookDelivery, error) {
+	var payload eventPayload
+	if err := json.Unmarshal(r.Payload, &payload); err != nil {
+		return core.WebhookDelivery{}, err
+	}
+
+	subscription := subscriptionRow{
+		ID:         r.SubscriptionID,
+		URL:        r.URL,
+		Secret:     r.Secret,
+		EventTypes: r.EventTypes,
+		CreatedAt:  r.SubscriptionCreatedAt,
+	}
+	coreSubscription, err := subscription.toCoreSubscription()
+	if err != nil {
+		return core.WebhookDelivery{}, err
+	}
+
+	return core.WebhookDelivery{
 		ID:           r.ID,
-		Name:         r.Name,
-		AuthorID:     r.AuthorID,
-		Status:       core.PullRequestStatus(r.Status),
-		ReviewersIDs: reviewerIDs,
+		Event:        payload.toCoreEvent(r.EventID, r.EventType, r.EventCreatedAt),
+		Subscription: coreSubscription,
+		Attempts:     r.Attempts,
+	}, nil
+}
+
+// значения GROUPING(team_name, author_id) для наборов группировки статистики PR
+const (
+	groupingTeam   = 1
+	groupingAuthor = 2
+	groupingTotal  = 3
+)
+
+type prStatisticsRow struct {
+	Grouping   int             `db:"grouping"`
+	TeamName   string          `db:"team_name"`
+	AuthorID   string          `db:"author_id"`
+	OpenPRs    int             `db:"open_prs"`
+	MergedPRs  int             `db:"merged_prs"`
+	AvgSeconds sql.NullFloat64 `db:"avg_seconds"`
+	P50Seconds sql.NullFloat64 `db:"p50_seconds"`
+	P90Seconds sql.NullFloat64 `db:"p90_seconds"`
+}
+
+func (r *prStatisticsRow) toCore() core.PRStatistics {
+	return core.PRStatistics{
+		OpenPRs:   r.OpenPRs,
+		MergedPRs: r.MergedPRs,
+		TimeToMerge: core.CycleTime{
+			Count:   r.MergedPRs,
+			Average: secondsToDuration(r.AvgSeconds),
+			P50:     secondsToDuration(r.P50Seconds),
+			P90:     secondsToDuration(r.P90Seconds),
+		},
+	}
+}
+
+func secondsToDuration(seconds sql.NullFloat64) time.Duration {
+	return time.Duration(seconds.Float64 * float64(time.Second))
+}
+
+type weeklyAssignmentsRow struct {
+	ReviewerID string    `db:"reviewer_id"`
+	WeekStart  time.Time `db:"week_start"`
+	Count      int       `db:"count"`
+}
+
+type reassignmentsRow struct {
+	ReplacedReviewerID string `db:"replaced_reviewer_id"`
+	Reason             string `db:"reason"`
+	Count              int    `db:"count"`
+}
+
+type tokenRow struct {
+	ID        int64          `db:"id"`
+	Name      string         `db:"name"`
+	Role      string         `db:"role"`
+	UserID    sql.NullString `db:"user_id"`
+	Hash      string         `db:"token_hash"`
+	CreatedAt time.Time      `db:"created_at"`
+	RevokedAt sql.NullTime   `db:"revoked_at"`
+}
+
+func (r *tokenRow) toCoreToken() core.APIToken {
+	token := core.APIToken{
+		ID:        r.ID,
+		Name:      r.Name,
+		Role:      core.Role(r.Role),
+		UserID:    r.UserID.String,
+		Hash:      r.Hash,
+		CreatedAt: r.CreatedAt,
+	}
+	if r.RevokedAt.Valid {
+		revokedAt := r.RevokedAt.Time
+		token.RevokedAt = &revokedAt
+	}
+	return token
+}
+
+type auditRow struct {
+	ID        int64          `db:"id"`
+	ActorID   sql.NullString `db:"actor_id"`
+	TokenID   sql.NullInt64  `db:"token_id"`
+	TokenName sql.NullString `db:"token_name"`
+	Role      sql.NullString `db:"role"`
+	Operation string         `db:"operation"`
+	EntityID  string         `db:"entity_id"`
+	Payload   []byte         `db:"payload"`
+	Result    []byte         `db:"result"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (r *auditRow) toCoreRecord() core.AuditRecord {
+	return core.AuditRecord{
+		ID:        r.ID,
+		ActorID:   r.ActorID.String,
+		TokenID:   r.TokenID.Int64,
+		TokenName: r.TokenName.String,
+		Role:      core.Role(r.Role.String),
+		Operation: core.AuditOperation(r.Operation),
+		EntityID:  r.EntityID,
+		Payload:   r.Payload,
+		Result:    r.Result,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type idempotencyRow struct {
+	Scope          string        `db:"scope"`
+	Key            string        `db:"idempotency_key"`
+	Fingerprint    string        `db:"fingerprint"`
+	ResponseStatus sql.NullInt32 `db:"response_status"`
+	ResponseBody   []byte        `db:"response_body"`
+	CreatedAt      time.Time     `db:"created_at"`
+	ExpiresAt      time.Time     `db:"expires_at"`
+}
+
+func (r *idempotencyRow) toCoreRecord() core.IdempotencyRecord {
+	record := core.IdempotencyRecord{
+		Scope:       r.Scope,
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+	if r.ResponseStatus.Valid {
+		record.Response = &core.IdempotentResponse{StatusCode: int(r.ResponseStatus.Int32), Body: r.ResponseBody}
 	}
+	return record
 }
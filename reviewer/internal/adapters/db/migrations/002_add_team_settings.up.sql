@@ -0,0 +1,2 @@
+-- Стратегия выбора ревьюверов, NULL означает стратегию из конфига
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32);
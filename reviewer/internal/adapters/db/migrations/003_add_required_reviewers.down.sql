@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_reviewers;
+ALTER TABLE teams DROP COLUMN IF EXISTS required_reviewers;
@@ -0,0 +1,5 @@
+-- Сколько ревьюверов назначать на PR команды
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_reviewers INTEGER NOT NULL DEFAULT 2 CHECK (required_reviewers > 0);
+
+-- Требование команды на момент создания PR, чтобы видеть PR с недобором ревьюверов
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS required_reviewers INTEGER NOT NULL DEFAULT 2;
@@ -0,0 +1,11 @@
+-- Соответствие логинов внешних систем (GitHub и т.п.) пользователям
+CREATE TABLE IF NOT EXISTS external_accounts (
+    provider VARCHAR(32) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (provider, login),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Индекс для поиска логинов пользователя
+CREATE INDEX IF NOT EXISTS idx_external_accounts_user_id ON external_accounts(user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS state;
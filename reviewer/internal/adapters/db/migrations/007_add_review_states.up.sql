@@ -0,0 +1,5 @@
+-- Состояние ревью каждого назначенного ревьювера
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS state VARCHAR(32) NOT NULL DEFAULT 'PENDING'
+    CHECK (state IN ('PENDING', 'APPROVED', 'CHANGES_REQUESTED', 'DISMISSED'));
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS merge_override_reason;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS merge_override_actor;
+ALTER TABLE teams DROP COLUMN IF EXISTS required_approvals;
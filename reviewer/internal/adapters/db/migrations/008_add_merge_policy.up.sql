@@ -0,0 +1,6 @@
+-- Сколько одобрений нужно для merge, 0 отключает проверку
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0 CHECK (required_approvals >= 0);
+
+-- Кто и почему смержил PR в обход политики команды
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS merge_override_actor VARCHAR(255);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS merge_override_reason TEXT;
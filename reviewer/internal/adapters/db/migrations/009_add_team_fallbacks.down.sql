@@ -0,0 +1,2 @@
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS fallback_team;
+DROP TABLE IF EXISTS team_fallbacks;
@@ -0,0 +1,13 @@
+-- Запасные команды, из которых добираются ревьюверы, по порядку обращения
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_name VARCHAR(255) NOT NULL,
+    fallback_team_name VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (team_name, fallback_team_name),
+    FOREIGN KEY (team_name) REFERENCES teams(name) ON DELETE CASCADE,
+    FOREIGN KEY (fallback_team_name) REFERENCES teams(name) ON DELETE CASCADE,
+    CHECK (team_name <> fallback_team_name)
+);
+
+-- Запасная команда, из которой назначен ревьювер, NULL для команды автора
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255);
@@ -0,0 +1,5 @@
+-- Правила владения кодом команды: [{"pattern": "...", "users": [...], "teams": [...]}]
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS code_owners JSONB NOT NULL DEFAULT '[]';
+
+-- Шаблон правила владения кодом, по которому назначен ревьювер
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS owner_pattern TEXT;
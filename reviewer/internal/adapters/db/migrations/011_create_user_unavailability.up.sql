@@ -0,0 +1,12 @@
+-- Периоды отсутствия пользователей [starts_at, ends_at), в которые они не назначаются на ревью
+CREATE TABLE IF NOT EXISTS user_unavailability (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (starts_at < ends_at)
+);
+
+-- Индекс для проверки доступности кандидатов
+CREATE INDEX IF NOT EXISTS idx_user_unavailability_user_id ON user_unavailability(user_id, ends_at);
@@ -0,0 +1,2 @@
+-- Заменять деактивированного участника во всех открытых PR
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS auto_reassign BOOLEAN NOT NULL DEFAULT false;
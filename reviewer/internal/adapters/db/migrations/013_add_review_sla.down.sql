@@ -0,0 +1,2 @@
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS escalated_at;
+ALTER TABLE teams DROP COLUMN IF EXISTS review_sla_seconds;
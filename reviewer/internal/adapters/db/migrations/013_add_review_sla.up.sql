@@ -0,0 +1,6 @@
+-- SLA ревью команды в секундах, 0 - без SLA
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS review_sla_seconds INTEGER NOT NULL DEFAULT 0
+    CHECK (review_sla_seconds >= 0);
+
+-- Время эскалации просроченного ревью
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP;
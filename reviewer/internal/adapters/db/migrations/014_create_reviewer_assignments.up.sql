@@ -0,0 +1,23 @@
+-- История назначений ревьюверов: записи только добавляются, при снятии ревьювера
+-- проставляется unassigned_at
+CREATE TABLE IF NOT EXISTS reviewer_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    reviewer_id VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP NOT NULL,
+    unassigned_at TIMESTAMP,
+    reason VARCHAR(32) NOT NULL CHECK (reason IN ('initial', 'reassign', 'deactivation', 'reopen', 'sla')),
+    replaced_reviewer_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (unassigned_at IS NULL OR unassigned_at >= assigned_at)
+);
+
+-- Индекс для истории PR и поиска открытого назначения ревьювера
+CREATE INDEX IF NOT EXISTS idx_reviewer_assignments_pr ON reviewer_assignments(pull_request_id, reviewer_id);
+
+-- Текущие ревьюверы существующих PR считаются назначенными при создании
+INSERT INTO reviewer_assignments (pull_request_id, reviewer_id, assigned_at, reason)
+SELECT pull_request_id, reviewer_id, assigned_at, 'initial'
+FROM pull_request_reviewers;
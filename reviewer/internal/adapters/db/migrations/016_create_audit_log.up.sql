@@ -0,0 +1,24 @@
+-- Журнал аудита изменяющих операций. Внешних ключей нет: записи переживают удаление объектов
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id VARCHAR(255),
+    -- токен запроса отдельно от заявленного actor_id, который у администратора задаётся X-Actor-ID
+    token_id BIGINT,
+    token_name VARCHAR(255),
+    role VARCHAR(16),
+    operation VARCHAR(32) NOT NULL
+        CHECK (operation IN (
+            'create_team', 'update_team_settings', 'set_ownership_rules', 'set_user_active',
+            'set_user_unavailability', 'create_pr', 'merge_pr', 'submit_review', 'close_pr', 'reopen_pr',
+            'reassign_reviewer', 'deactivate_team_user', 'create_token', 'revoke_token'
+        )),
+    entity_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    result JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Индексы для фильтров GET /audit
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
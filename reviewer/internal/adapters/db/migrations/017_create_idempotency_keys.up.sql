@@ -0,0 +1,15 @@
+-- Ключи Idempotency-Key и ответы на запросы с ними; response_status пуст, пока запрос выполняется,
+-- и до ответа expires_at - конец аренды ключа, а не срок хранения ответа
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    response_status INTEGER,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+-- Индекс для удаления просроченных ключей
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
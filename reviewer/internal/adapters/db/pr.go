@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"pr-reviewer/internal/core"
@@ -18,53 +20,35 @@ func NewPRRepository(database *DB) *PRRepository {
 }
 
 func (r *PRRepository) Create(ctx context.Context, pr *core.PullRequest) error {
-	tx, err := r.db.conn.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	committed := false
-	defer func() {
-		if !committed {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				r.db.log.Error("failed to rollback transaction", "error", rollbackErr)
-			}
+	return r.db.withTx(ctx, func(tx querier) error {
+		var mergedAt sql.NullTime
+		if pr.Status == core.PullRequestStatusMerged {
+			mergedAt = prMergedAt(pr)
+		}
+		createdAt := pr.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
 		}
-	}()
-
-	var mergedAt sql.NullTime
-	if pr.Status == core.PullRequestStatusMerged {
-		mergedAt = sql.NullTime{Time: time.Now(), Valid: true}
-	}
-
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO pull_requests (id, name, author_id, status, created_at, merged_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), time.Now(), mergedAt)
-	if err != nil {
-		return err
-	}
 
-	for _, reviewerID := range pr.ReviewersIDs {
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id)
-			VALUES ($1, $2)
-		`, pr.ID, reviewerID)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO pull_requests (id, name, author_id, status, required_reviewers, created_at, merged_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, pr.ID, pr.Name, pr.AuthorID, string(pr.Status), pr.RequiredReviewers, createdAt.UTC(), mergedAt)
 		if err != nil {
 			return err
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	committed = true
-	return nil
+		return upsertReviewers(ctx, tx, pr)
+	})
 }
 
 func (r *PRRepository) GetByID(ctx context.Context, id string) (*core.PullRequest, error) {
+	q := r.db.querier(ctx)
+
 	var row prRow
-	err := r.db.conn.GetContext(ctx, &row, `
-		SELECT id, name, author_id, status, created_at, merged_at
+	err := q.GetContext(ctx, &row, `
+		SELECT id, name, author_id, status, required_reviewers, created_at, merged_at,
+			merge_override_actor, merge_override_reason
 		FROM pull_requests
 		WHERE id = $1
 	`, id)
@@ -75,73 +59,62 @@ func (r *PRRepository) GetByID(ctx context.Context, id string) (*core.PullReques
 		return nil, err
 	}
 
-	var reviewerIDs []string
-	err = r.db.conn.SelectContext(ctx, &reviewerIDs, `
-		SELECT reviewer_id
+	var reviews []reviewRow
+	err = q.SelectContext(ctx, &reviews, `
+		SELECT pull_request_id, reviewer_id, state, assigned_at, updated_at, fallback_team, owner_pattern,
+			escalated_at
 		FROM pull_request_reviewers
 		WHERE pull_request_id = $1
+		ORDER BY assigned_at, reviewer_id
 	`, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return row.toCorePullRequest(reviewerIDs), nil
+	return row.toCorePullRequest(reviews), nil
 }
 
 func (r *PRRepository) Update(ctx context.Context, pr *core.PullRequest) error {
-	tx, err := r.db.conn.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	committed := false
-	defer func() {
-		if !committed {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				r.db.log.Error("failed to rollback transaction", "error", rollbackErr)
-			}
+	return r.db.withTx(ctx, func(tx querier) error {
+		var overrideActor, overrideReason sql.NullString
+		if pr.MergeOverride != nil {
+			overrideActor = sql.NullString{String: pr.MergeOverride.ActorID, Valid: true}
+			overrideReason = sql.NullString{String: pr.MergeOverride.Reason, Valid: true}
 		}
-	}()
-
-	var mergedAt sql.NullTime
-	if pr.Status == core.PullRequestStatusMerged {
-		mergedAt = sql.NullTime{Time: time.Now(), Valid: true}
-	}
-
-	_, err = tx.ExecContext(ctx, `
-		UPDATE pull_requests
-		SET name = $1, status = $2, merged_at = $3
-		WHERE id = $4
-	`, pr.Name, string(pr.Status), mergedAt, pr.ID)
-	if err != nil {
-		return err
-	}
 
-	_, err = tx.ExecContext(ctx, "DELETE FROM pull_request_reviewers WHERE pull_request_id = $1", pr.ID)
-	if err != nil {
-		return err
-	}
+		// closed_at сохраняет время первого закрытия, пока PR не открыт заново,
+		// merged_at - время первого merge
+		_, err := tx.ExecContext(ctx, `
+			UPDATE pull_requests
+			SET name = $1, status = $2,
+				merged_at = CASE WHEN $2 = $9 THEN COALESCE(merged_at, $3) END,
+				closed_at = CASE WHEN $2 = $5 THEN COALESCE(closed_at, $6) END,
+				merge_override_actor = $7, merge_override_reason = $8
+			WHERE id = $4
+		`, pr.Name, string(pr.Status), prMergedAt(pr), pr.ID, string(core.PullRequestStatusClosed), time.Now().UTC(),
+			overrideActor, overrideReason, string(core.PullRequestStatusMerged))
+		if err != nil {
+			return err
+		}
 
-	for _, reviewerID := range pr.ReviewersIDs {
+		// ревью оставшихся ревьюверов сохраняются вместе с временем назначения
 		_, err = tx.ExecContext(ctx, `
-			INSERT INTO pull_request_reviewers (pull_request_id, reviewer_id)
-			VALUES ($1, $2)
-		`, pr.ID, reviewerID)
+			DELETE FROM pull_request_reviewers
+			WHERE pull_request_id = $1 AND reviewer_id <> ALL($2)
+		`, pr.ID, append([]string{}, pr.ReviewersIDs...))
 		if err != nil {
 			return err
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	committed = true
-	return nil
+		return upsertReviewers(ctx, tx, pr)
+	})
 }
 
 func (r *PRRepository) GetByReviewerID(ctx context.Context, userID string) ([]*core.PullRequest, error) {
 	var rows []prRow
-	err := r.db.conn.SelectContext(ctx, &rows, `
-		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.created_at, pr.merged_at
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.required_reviewers, pr.created_at, pr.merged_at,
+			pr.merge_override_actor, pr.merge_override_reason
 		FROM pull_requests pr
 		INNER JOIN pull_request_reviewers prr ON pr.id = prr.pull_request_id
 		WHERE prr.reviewer_id = $1
@@ -150,43 +123,215 @@ func (r *PRRepository) GetByReviewerID(ctx context.Context, userID string) ([]*c
 		return nil, err
 	}
 
-	result := make([]*core.PullRequest, len(rows))
-	for i, row := range rows {
-		var reviewerIDs []string
-		err = r.db.conn.SelectContext(ctx, &reviewerIDs, `
-			SELECT reviewer_id
-			FROM pull_request_reviewers
-			WHERE pull_request_id = $1
-		`, row.ID)
-		if err != nil {
-			return nil, err
-		}
+	return r.withReviewers(ctx, rows)
+}
 
-		result[i] = row.toCorePullRequest(reviewerIDs)
+// List собирает условие WHERE из заданных полей фильтра. Курсор сравнивается по паре
+// (created_at, id), поэтому страницы не пересекаются при одинаковом времени создания.
+func (r *PRRepository) List(ctx context.Context, filter core.PRFilter) ([]*core.PullRequest, error) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
 	}
-	return result, nil
+
+	if filter.Status != "" {
+		conditions = append(conditions, "pr.status = "+arg(string(filter.Status)))
+	}
+	if filter.AuthorID != "" {
+		conditions = append(conditions, "pr.author_id = "+arg(filter.AuthorID))
+	}
+	if filter.ReviewerID != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM pull_request_reviewers prr
+			WHERE prr.pull_request_id = pr.id AND prr.reviewer_id = `+arg(filter.ReviewerID)+`
+		)`)
+	}
+	if filter.TeamName != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM users u WHERE u.id = pr.author_id AND u.team_name = `+arg(filter.TeamName)+`
+		)`)
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "pr.created_at >= "+arg(filter.CreatedFrom.UTC()))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "pr.created_at < "+arg(filter.CreatedTo.UTC()))
+	}
+	if filter.MergedFrom != nil {
+		conditions = append(conditions, "pr.merged_at >= "+arg(filter.MergedFrom.UTC()))
+	}
+	if filter.MergedTo != nil {
+		conditions = append(conditions, "pr.merged_at < "+arg(filter.MergedTo.UTC()))
+	}
+	if filter.After != nil {
+		conditions = append(conditions,
+			"(pr.created_at, pr.id) > ("+arg(filter.After.CreatedAt.UTC())+"::timestamp, "+arg(filter.After.ID)+")")
+	}
+
+	query := `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.required_reviewers, pr.created_at, pr.merged_at,
+			pr.merge_override_actor, pr.merge_override_reason
+		FROM pull_requests pr`
+	if len(conditions) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	}
+	query += "\n\t\tORDER BY pr.created_at, pr.id"
+	if filter.Limit > 0 {
+		query += "\n\t\tLIMIT " + arg(filter.Limit)
+	}
+
+	var rows []prRow
+	if err := r.db.querier(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	return r.withReviewers(ctx, rows)
 }
 
-func (r *PRRepository) GetStatistics(ctx context.Context) (map[string]int, error) {
+// GetOpenByReviewerIDs возвращает OPEN PR, где ревьювером назначен хотя бы один из пользователей.
+func (r *PRRepository) GetOpenByReviewerIDs(ctx context.Context, userIDs []string) ([]*core.PullRequest, error) {
+	var rows []prRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.required_reviewers, pr.created_at, pr.merged_at,
+			pr.merge_override_actor, pr.merge_override_reason
+		FROM pull_requests pr
+		WHERE pr.status = $1 AND EXISTS (
+			SELECT 1
+			FROM pull_request_reviewers prr
+			WHERE prr.pull_request_id = pr.id AND prr.reviewer_id = ANY($2)
+		)
+		ORDER BY pr.created_at, pr.id
+	`, string(core.PullRequestStatusOpen), userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withReviewers(ctx, rows)
+}
+
+// GetOverdue возвращает OPEN PR, где PENDING ревью ждёт дольше review SLA команды автора.
+func (r *PRRepository) GetOverdue(ctx context.Context, now time.Time) ([]*core.PullRequest, error) {
+	var rows []prRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT pr.id, pr.name, pr.author_id, pr.status, pr.required_reviewers, pr.created_at, pr.merged_at,
+			pr.merge_override_actor, pr.merge_override_reason
+		FROM pull_requests pr
+		INNER JOIN users u ON u.id = pr.author_id
+		INNER JOIN teams t ON t.name = u.team_name
+		WHERE pr.status = $1 AND t.review_sla_seconds > 0 AND EXISTS (
+			SELECT 1
+			FROM pull_request_reviewers prr
+			WHERE prr.pull_request_id = pr.id AND prr.state = $2
+				AND prr.assigned_at + t.review_sla_seconds * INTERVAL '1 second' <= $3
+		)
+		ORDER BY pr.created_at, pr.id
+	`, string(core.PullRequestStatusOpen), string(core.ReviewStatePending), now.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withReviewers(ctx, rows)
+}
+
+func (r *PRRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
 	var stats []struct {
 		UserID string `db:"reviewer_id"`
 		Count  int    `db:"count"`
 	}
 
-	err := r.db.conn.SelectContext(ctx, &stats, `
-		SELECT reviewer_id, COUNT(*) as count
-		FROM pull_request_reviewers
-		GROUP BY reviewer_id
-		ORDER BY count DESC
-	`)
+	err := r.db.querier(ctx).SelectContext(ctx, &stats, `
+		SELECT prr.reviewer_id, COUNT(*) as count
+		FROM pull_request_reviewers prr
+		INNER JOIN pull_requests pr ON pr.id = prr.pull_request_id
+		WHERE pr.status = $1 AND prr.reviewer_id = ANY($2)
+		GROUP BY prr.reviewer_id
+	`, string(core.PullRequestStatusOpen), userIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make(map[string]int)
+	result := make(map[string]int, len(stats))
 	for _, stat := range stats {
 		result[stat.UserID] = stat.Count
 	}
 
 	return result, nil
 }
+
+// withReviewers загружает ревьюверов для всех PR одним запросом.
+func (r *PRRepository) withReviewers(ctx context.Context, rows []prRow) ([]*core.PullRequest, error) {
+	prIDs := make([]string, len(rows))
+	for i, row := range rows {
+		prIDs[i] = row.ID
+	}
+
+	var reviews []reviewRow
+	err := r.db.querier(ctx).SelectContext(ctx, &reviews, `
+		SELECT pull_request_id, reviewer_id, state, assigned_at, updated_at, fallback_team, owner_pattern,
+			escalated_at
+		FROM pull_request_reviewers
+		WHERE pull_request_id = ANY($1)
+		ORDER BY assigned_at, reviewer_id
+	`, prIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsByPR := make(map[string][]reviewRow, len(rows))
+	for _, review := range reviews {
+		reviewsByPR[review.PullRequestID] = append(reviewsByPR[review.PullRequestID], review)
+	}
+
+	result := make([]*core.PullRequest, len(rows))
+	for i, row := range rows {
+		result[i] = row.toCorePullRequest(reviewsByPR[row.ID])
+	}
+	return result, nil
+}
+
+// prMergedAt возвращает время merge из PR, а если оно не задано - текущее время.
+func prMergedAt(pr *core.PullRequest) sql.NullTime {
+	if pr.MergedAt != nil {
+		return sql.NullTime{Time: pr.MergedAt.UTC(), Valid: true}
+	}
+	return sql.NullTime{Time: time.Now().UTC(), Valid: true}
+}
+
+// upsertReviewers добавляет новых ревьюверов и обновляет состояние и эскалацию ревью у существующих.
+// updated_at меняется только вместе с состоянием.
+func upsertReviewers(ctx context.Context, tx querier, pr *core.PullRequest) error {
+	now := time.Now()
+	for _, reviewerID := range pr.ReviewersIDs {
+		review := pr.Review(reviewerID)
+		if review.AssignedAt.IsZero() {
+			review.AssignedAt = now
+		}
+		if review.UpdatedAt.IsZero() {
+			review.UpdatedAt = now
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO pull_request_reviewers (
+				pull_request_id, reviewer_id, state, assigned_at, updated_at, fallback_team, owner_pattern,
+				escalated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (pull_request_id, reviewer_id) DO UPDATE
+			SET state = EXCLUDED.state,
+				updated_at = CASE
+					WHEN pull_request_reviewers.state <> EXCLUDED.state THEN EXCLUDED.updated_at
+					ELSE pull_request_reviewers.updated_at
+				END,
+				escalated_at = EXCLUDED.escalated_at
+			WHERE pull_request_reviewers.state <> EXCLUDED.state
+				OR pull_request_reviewers.escalated_at IS DISTINCT FROM EXCLUDED.escalated_at
+		`, pr.ID, reviewerID, string(review.State), review.AssignedAt.UTC(), review.UpdatedAt.UTC(),
+			nullString(review.FallbackTeam), nullString(review.OwnerPattern), nullTime(review.EscalatedAt))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+// GetStatistics считает показатели в SQL: время до merge - через percentile_cont по группам
+// GROUPING SETS, назначения - по неделям date_trunc, переназначения - по истории назначений.
+func (r *PRRepository) GetStatistics(ctx context.Context, filter core.StatisticsFilter) (*core.Statistics, error) {
+	from, to := utcTime(filter.From), utcTime(filter.To)
+	q := r.db.querier(ctx)
+
+	var groups []prStatisticsRow
+	err := q.SelectContext(ctx, &groups, `
+		WITH scoped AS (
+			SELECT
+				u.team_name,
+				pr.author_id,
+				pr.status = $1
+					AND ($2::timestamp IS NULL OR pr.created_at >= $2::timestamp)
+					AND ($3::timestamp IS NULL OR pr.created_at < $3::timestamp) AS is_open,
+				pr.merged_at IS NOT NULL
+					AND ($2::timestamp IS NULL OR pr.merged_at >= $2::timestamp)
+					AND ($3::timestamp IS NULL OR pr.merged_at < $3::timestamp) AS is_merged,
+				EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)::double precision AS merge_seconds
+			FROM pull_requests pr
+			INNER JOIN users u ON u.id = pr.author_id
+			WHERE $4::text = '' OR u.team_name = $4::text
+		)
+		SELECT
+			GROUPING(team_name, author_id) AS grouping,
+			COALESCE(team_name, '') AS team_name,
+			COALESCE(author_id, '') AS author_id,
+			COUNT(*) FILTER (WHERE is_open) AS open_prs,
+			COUNT(*) FILTER (WHERE is_merged) AS merged_prs,
+			AVG(merge_seconds) FILTER (WHERE is_merged) AS avg_seconds,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY merge_seconds) FILTER (WHERE is_merged) AS p50_seconds,
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY merge_seconds) FILTER (WHERE is_merged) AS p90_seconds
+		FROM scoped
+		GROUP BY GROUPING SETS ((team_name), (author_id), ())
+		HAVING GROUPING(team_name, author_id) = 3 OR COUNT(*) FILTER (WHERE is_open OR is_merged) > 0
+	`, string(core.PullRequestStatusOpen), from, to, filter.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignments []weeklyAssignmentsRow
+	err = q.SelectContext(ctx, &assignments, `
+		SELECT ra.reviewer_id, date_trunc('week', ra.assigned_at) AS week_start, COUNT(*) AS count
+		FROM reviewer_assignments ra
+		INNER JOIN pull_requests pr ON pr.id = ra.pull_request_id
+		INNER JOIN users u ON u.id = pr.author_id
+		WHERE ($1::text = '' OR u.team_name = $1::text)
+			AND ($2::timestamp IS NULL OR ra.assigned_at >= $2::timestamp)
+			AND ($3::timestamp IS NULL OR ra.assigned_at < $3::timestamp)
+		GROUP BY ra.reviewer_id, week_start
+	`, filter.TeamName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var reassignments []reassignmentsRow
+	err = q.SelectContext(ctx, &reassignments, `
+		SELECT ra.replaced_reviewer_id, ra.reason, COUNT(*) AS count
+		FROM reviewer_assignments ra
+		INNER JOIN pull_requests pr ON pr.id = ra.pull_request_id
+		INNER JOIN users u ON u.id = pr.author_id
+		WHERE ra.replaced_reviewer_id IS NOT NULL
+			AND ($1::text = '' OR u.team_name = $1::text)
+			AND ($2::timestamp IS NULL OR ra.assigned_at >= $2::timestamp)
+			AND ($3::timestamp IS NULL OR ra.assigned_at < $3::timestamp)
+		GROUP BY ra.replaced_reviewer_id, ra.reason
+	`, filter.TeamName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCoreStatistics(groups, assignments, reassignments), nil
+}
+
+func toCoreStatistics(groups []prStatisticsRow, assignments []weeklyAssignmentsRow, reassignments []reassignmentsRow) *core.Statistics {
+	stats := &core.Statistics{Reassignments: make(map[core.AssignmentReason]int)}
+	users := make(map[string]*core.UserStatistics)
+	user := func(userID string) *core.UserStatistics {
+		if users[userID] == nil {
+			users[userID] = &core.UserStatistics{UserID: userID}
+		}
+		return users[userID]
+	}
+
+	for _, group := range groups {
+		switch group.Grouping {
+		case groupingTeam:
+			stats.Teams = append(stats.Teams, core.TeamStatistics{TeamName: group.TeamName, PRStatistics: group.toCore()})
+		case groupingAuthor:
+			user(group.AuthorID).PRStatistics = group.toCore()
+		case groupingTotal:
+			stats.PRStatistics = group.toCore()
+		}
+	}
+
+	weeks := make(map[time.Time]int)
+	for _, row := range assignments {
+		user(row.ReviewerID).Assignments += row.Count
+		weeks[row.WeekStart] += row.Count
+		stats.Assignments += row.Count
+	}
+	for start, count := range weeks {
+		stats.AssignmentsPerWeek = append(stats.AssignmentsPerWeek, core.WeeklyAssignments{WeekStart: start, Assignments: count})
+	}
+
+	for _, row := range reassignments {
+		user(row.ReplacedReviewerID).Reassignments += row.Count
+		stats.Reassignments[core.AssignmentReason(row.Reason)] += row.Count
+	}
+
+	for _, userStats := range users {
+		stats.Users = append(stats.Users, *userStats)
+	}
+	return stats
+}
+
+// utcTime приводит необязательную границу окна к UTC, как и все времена в столбцах TIMESTAMP.
+func utcTime(at *time.Time) any {
+	if at == nil {
+		return nil
+	}
+	return at.UTC()
+}
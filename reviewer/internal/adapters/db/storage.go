@@ -1,8 +1,11 @@
 package db
 
 import (
+	"context"
 	"log/slog"
 
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -10,15 +13,33 @@ type DB struct {
 	log  *slog.Logger
 	conn *sqlx.DB
 
-	Team *TeamRepository
-	User *UserRepository
-	PR   *PRRepository
+	Team        *TeamRepository
+	User        *UserRepository
+	PR          *PRRepository
+	Event       *EventRepository
+	Webhook     *WebhookRepository
+	Account     *AccountRepository
+	Assignment  *AssignmentRepository
+	Token       *TokenRepository
+	Audit       *AuditRepository
+	Idempotency *IdempotencyRepository
 }
 
+// New подключается к PostgreSQL. Столбцы TIMESTAMP хранят время в UTC без часового пояса:
+// репозитории приводят к UTC все передаваемые времена, а сессия работает в UTC, чтобы
+// так же считались значения по умолчанию CURRENT_TIMESTAMP.
 func New(log *slog.Logger, address string) (*DB, error) {
-	conn, err := sqlx.Connect("pgx", address)
+	config, err := pgx.ParseConfig(address)
 	if err != nil {
+		log.Error("invalid db address", "error", err)
+		return nil, err
+	}
+	config.RuntimeParams["timezone"] = "UTC"
+
+	conn := sqlx.NewDb(stdlib.OpenDB(*config), "pgx")
+	if err := conn.Ping(); err != nil {
 		log.Error("connection problem", "address", address, "error", err)
+		_ = conn.Close()
 		return nil, err
 	}
 
@@ -30,6 +51,13 @@ func New(log *slog.Logger, address string) (*DB, error) {
 	db.Team = NewTeamRepository(db)
 	db.User = NewUserRepository(db)
 	db.PR = NewPRRepository(db)
+	db.Event = NewEventRepository(db)
+	db.Webhook = NewWebhookRepository(db)
+	db.Account = NewAccountRepository(db)
+	db.Assignment = NewAssignmentRepository(db)
+	db.Token = NewTokenRepository(db)
+	db.Audit = NewAuditRepository(db)
+	db.Idempotency = NewIdempotencyRepository(db)
 
 	return db, nil
 }
@@ -42,3 +70,70 @@ func (db *DB) Close() error {
 func (db *DB) Conn() *sqlx.DB {
 	return db.conn
 }
+
+type txKey struct{}
+
+// querier - общие методы sqlx.DB и sqlx.Tx.
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// querier возвращает транзакцию из контекста, если она открыта через WithinTx.
+// Каждый запрос через него пишет спан трассировки.
+func (db *DB) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tracedQuerier{tx}
+	}
+	return tracedQuerier{db.conn}
+}
+
+// WithinTx выполняет fn в одной транзакции. Репозитории, вызванные с переданным
+// контекстом, работают в ней же; вложенные вызовы присоединяются к внешней транзакции.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracer().Start(ctx, "DB.WithinTx")
+	err := db.beginTx(ctx, func(tx *sqlx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	endSpan(span, err)
+	return err
+}
+
+func (db *DB) withTx(ctx context.Context, fn func(tx querier) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(tracedQuerier{tx})
+	}
+	return db.beginTx(ctx, func(tx *sqlx.Tx) error {
+		return fn(tracedQuerier{tx})
+	})
+}
+
+func (db *DB) beginTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				db.log.Error("failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"pr-reviewer/internal/core"
 )
@@ -17,46 +19,54 @@ func NewTeamRepository(database *DB) *TeamRepository {
 }
 
 func (r *TeamRepository) Create(ctx context.Context, team *core.Team) error {
-	tx, err := r.db.conn.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	committed := false
-	defer func() {
-		if !committed {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				r.db.log.Error("failed to rollback transaction", "error", rollbackErr)
-			}
+	return r.db.withTx(ctx, func(tx querier) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO teams (
+				name, reviewer_strategy, required_reviewers, required_approvals, auto_reassign, review_sla_seconds
+			)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (name) DO NOTHING
+		`, team.Name, nullString(string(team.Settings.ReviewerStrategy)), team.Settings.RequiredReviewers,
+			team.Settings.RequiredApprovals, team.Settings.AutoReassign, slaSeconds(team.Settings.ReviewSLA))
+		if err != nil {
+			return err
 		}
-	}()
 
-	_, err = tx.ExecContext(ctx, "INSERT INTO teams (name) VALUES ($1) ON CONFLICT (name) DO NOTHING", team.Name)
-	if err != nil {
-		return err
-	}
-
-	for _, member := range team.Members {
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO users (id, username, team_name, is_active) 
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (id) 
-			DO UPDATE SET username = $2, team_name = $3, is_active = $4
-		`, member.ID, member.Username, team.Name, member.IsActive)
+		created, err := result.RowsAffected()
 		if err != nil {
 			return err
 		}
-	}
+		if created > 0 {
+			if err := replaceFallbacks(ctx, tx, team.Name, team.Settings.FallbackTeams); err != nil {
+				return err
+			}
+		}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	committed = true
-	return nil
+		for _, member := range team.Members {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO users (id, username, team_name, is_active) 
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (id) 
+				DO UPDATE SET username = $2, team_name = $3, is_active = $4
+			`, member.ID, member.Username, team.Name, member.IsActive)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *TeamRepository) GetByName(ctx context.Context, name string) (*core.Team, error) {
-	var teamName string
-	err := r.db.conn.GetContext(ctx, &teamName, "SELECT name FROM teams WHERE name = $1", name)
+	q := r.db.querier(ctx)
+
+	var team teamRow
+	err := q.GetContext(ctx, &team, `
+		SELECT name, reviewer_strategy, required_reviewers, required_approvals, auto_reassign,
+			review_sla_seconds, code_owners
+		FROM teams
+		WHERE name = $1
+	`, name)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, core.ErrNotFound
@@ -65,7 +75,7 @@ func (r *TeamRepository) GetByName(ctx context.Context, name string) (*core.Team
 	}
 
 	var rows []userRow
-	err = r.db.conn.SelectContext(ctx, &rows, "SELECT id, username, team_name, is_active FROM users WHERE team_name = $1", name)
+	err = q.SelectContext(ctx, &rows, "SELECT id, username, team_name, is_active FROM users WHERE team_name = $1", name)
 	if err != nil {
 		return nil, err
 	}
@@ -75,8 +85,94 @@ func (r *TeamRepository) GetByName(ctx context.Context, name string) (*core.Team
 		members[i] = *row.toCoreUser()
 	}
 
+	var fallbackTeams []string
+	err = q.SelectContext(ctx, &fallbackTeams, `
+		SELECT fallback_team_name FROM team_fallbacks WHERE team_name = $1 ORDER BY position
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := team.toCoreOwnershipRules()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := team.toCoreSettings()
+	settings.FallbackTeams = fallbackTeams
 	return &core.Team{
-		Name:    teamName,
-		Members: members,
+		Name:           team.Name,
+		Members:        members,
+		Settings:       settings,
+		OwnershipRules: rules,
 	}, nil
 }
+
+func (r *TeamRepository) UpdateSettings(ctx context.Context, name string, settings core.TeamSettings) error {
+	return r.db.withTx(ctx, func(tx querier) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE teams
+			SET reviewer_strategy = $1, required_reviewers = $2, required_approvals = $3, auto_reassign = $4,
+				review_sla_seconds = $5
+			WHERE name = $6
+		`, nullString(string(settings.ReviewerStrategy)), settings.RequiredReviewers, settings.RequiredApprovals,
+			settings.AutoReassign, slaSeconds(settings.ReviewSLA), name)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return core.ErrNotFound
+		}
+
+		return replaceFallbacks(ctx, tx, name, settings.FallbackTeams)
+	})
+}
+
+func (r *TeamRepository) UpdateOwnershipRules(ctx context.Context, name string, rules []core.OwnershipRule) error {
+	payload, err := json.Marshal(ownershipRulesFromCore(rules))
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.querier(ctx).ExecContext(ctx, "UPDATE teams SET code_owners = $1 WHERE name = $2", payload, name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+// slaSeconds округляет SLA вверх до целых секунд, чтобы ненулевой SLA не превратился в 0.
+func slaSeconds(sla time.Duration) int64 {
+	return int64((sla + time.Second - 1) / time.Second)
+}
+
+// replaceFallbacks заменяет запасные команды, сохраняя их порядок.
+func replaceFallbacks(ctx context.Context, tx querier, name string, fallbackTeams []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM team_fallbacks WHERE team_name = $1", name); err != nil {
+		return err
+	}
+
+	for position, fallbackTeam := range fallbackTeams {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO team_fallbacks (team_name, fallback_team_name, position)
+			VALUES ($1, $2, $3)
+		`, name, fallbackTeam, position)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
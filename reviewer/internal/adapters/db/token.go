@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type TokenRepository struct {
+	db *DB
+}
+
+func NewTokenRepository(database *DB) *TokenRepository {
+	return &TokenRepository{db: database}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *core.APIToken) error {
+	return r.db.querier(ctx).QueryRowxContext(ctx, `
+		INSERT INTO api_tokens (name, role, user_id, token_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, token.Name, string(token.Role), nullString(token.UserID), token.Hash).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*core.APIToken, error) {
+	var row tokenRow
+	err := r.db.querier(ctx).GetContext(ctx, &row, `
+		SELECT id, name, role, user_id, token_hash, created_at, revoked_at
+		FROM api_tokens
+		WHERE token_hash = $1
+	`, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := row.toCoreToken()
+	return &token, nil
+}
+
+func (r *TokenRepository) List(ctx context.Context) ([]core.APIToken, error) {
+	var rows []tokenRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT id, name, role, user_id, token_hash, created_at, revoked_at
+		FROM api_tokens
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.APIToken, len(rows))
+	for i, row := range rows {
+		result[i] = row.toCoreToken()
+	}
+	return result, nil
+}
+
+func (r *TokenRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	result, err := r.db.querier(ctx).ExecContext(ctx,
+		"UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1", id, at.UTC())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer берётся из глобального TracerProvider при каждом спане, поэтому учитывает
+// провайдер, настроенный после создания DB.
+func tracer() trace.Tracer {
+	return otel.Tracer("pr-reviewer/internal/adapters/db")
+}
+
+// tracedQuerier пишет спан на каждый запрос. Спан называется по методу репозитория,
+// из которого выполнен запрос, например PRRepository.GetByID.
+type tracedQuerier struct {
+	querier
+}
+
+func (q tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := q.querier.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (q tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := q.querier.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (q tracedQuerier) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := q.querier.QueryxContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (q tracedQuerier) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := q.querier.QueryRowxContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (q tracedQuerier) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := startQuerySpan(ctx, query)
+	err := q.querier.GetContext(ctx, dest, query, args...)
+	endSpan(span, err)
+	return err
+}
+
+func (q tracedQuerier) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := startQuerySpan(ctx, query)
+	err := q.querier.SelectContext(ctx, dest, query, args...)
+	endSpan(span, err)
+	return err
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+	return tracer().Start(ctx, callerName(), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", strings.ToUpper(operation)),
+		attribute.String("db.statement", statement),
+	))
+}
+
+// callerName возвращает метод репозитория, вызвавший tracedQuerier:
+// "pr-reviewer/internal/adapters/db.(*PRRepository).Update.func1" -> "PRRepository.Update".
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(3)
+	if !ok {
+		return "db.query"
+	}
+	name := runtime.FuncForPC(pc).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.TrimPrefix(name, "db.")
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
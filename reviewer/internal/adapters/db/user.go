@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"pr-reviewer/internal/core"
 )
@@ -18,18 +19,31 @@ func NewUserRepository(database *DB) *UserRepository {
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*core.User, error) {
 	var row userRow
-	err := r.db.conn.GetContext(ctx, &row, "SELECT id, username, team_name, is_active FROM users WHERE id = $1", id)
+	err := r.db.querier(ctx).GetContext(ctx, &row, "SELECT id, username, team_name, is_active FROM users WHERE id = $1", id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, core.ErrNotFound
 		}
 		return nil, err
 	}
-	return row.toCoreUser(), nil
+
+	var windows []unavailabilityRow
+	err = r.db.querier(ctx).SelectContext(ctx, &windows, `
+		SELECT starts_at, ends_at FROM user_unavailability WHERE user_id = $1 ORDER BY starts_at
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user := row.toCoreUser()
+	for _, window := range windows {
+		user.Unavailability = append(user.Unavailability, window.toCoreUnavailability())
+	}
+	return user, nil
 }
 
 func (r *UserRepository) Update(ctx context.Context, user *core.User) error {
-	_, err := r.db.conn.ExecContext(ctx,
+	_, err := r.db.querier(ctx).ExecContext(ctx,
 		"UPDATE users SET username = $1, team_name = $2, is_active = $3 WHERE id = $4",
 		user.Username, user.TeamName, user.IsActive, user.ID,
 	)
@@ -38,10 +52,34 @@ func (r *UserRepository) Update(ctx context.Context, user *core.User) error {
 
 func (r *UserRepository) GetActiveByTeamName(ctx context.Context, teamName string) ([]*core.User, error) {
 	var rows []userRow
-	err := r.db.conn.SelectContext(ctx, &rows,
-		"SELECT id, username, team_name, is_active FROM users WHERE team_name = $1 AND is_active = true",
-		teamName,
-	)
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT id, username, team_name, is_active
+		FROM users
+		WHERE team_name = $1 AND is_active = true AND NOT EXISTS (
+			SELECT 1
+			FROM user_unavailability ua
+			WHERE ua.user_id = users.id AND ua.starts_at <= $2 AND ua.ends_at > $2
+		)
+	`, teamName, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*core.User, len(rows))
+	for i, row := range rows {
+		result[i] = row.toCoreUser()
+	}
+	return result, nil
+}
+
+func (r *UserRepository) SetActiveByTeam(ctx context.Context, teamName string, userIDs []string, isActive bool) ([]*core.User, error) {
+	var rows []userRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		UPDATE users
+		SET is_active = $1
+		WHERE team_name = $2 AND id = ANY($3)
+		RETURNING id, username, team_name, is_active
+	`, isActive, teamName, userIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -52,3 +90,22 @@ func (r *UserRepository) GetActiveByTeamName(ctx context.Context, teamName strin
 	}
 	return result, nil
 }
+
+func (r *UserRepository) SetUnavailability(ctx context.Context, userID string, windows []core.Unavailability) error {
+	return r.db.withTx(ctx, func(tx querier) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM user_unavailability WHERE user_id = $1", userID); err != nil {
+			return err
+		}
+
+		for _, window := range windows {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO user_unavailability (user_id, starts_at, ends_at)
+				VALUES ($1, $2, $3)
+			`, userID, window.From.UTC(), window.To.UTC())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type WebhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(database *DB) *WebhookRepository {
+	return &WebhookRepository{db: database}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *core.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(eventTypesToStrings(subscription.EventTypes))
+	if err != nil {
+		return err
+	}
+
+	return r.db.querier(ctx).QueryRowxContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, secret, event_types)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, subscription.URL, subscription.Secret, eventTypes).Scan(&subscription.ID, &subscription.CreatedAt)
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]core.WebhookSubscription, error) {
+	var rows []subscriptionRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		SELECT id, url, secret, event_types, created_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.WebhookSubscription, len(rows))
+	for i, row := range rows {
+		subscription, err := row.toCoreSubscription()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = subscription
+	}
+	return result, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result, err := r.db.querier(ctx).ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ScheduleDeliveries(ctx context.Context, limit int) (int, error) {
+	var scheduled int
+	now := time.Now().UTC()
+	err := r.db.withTx(ctx, func(tx querier) error {
+		var eventIDs []int64
+		err := tx.SelectContext(ctx, &eventIDs, `
+			SELECT id
+			FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		`, limit)
+		if err != nil {
+			return err
+		}
+		if len(eventIDs) == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (event_id, subscription_id, next_attempt_at)
+			SELECT e.id, s.id, $2
+			FROM outbox_events e
+			INNER JOIN webhook_subscriptions s
+				ON s.event_types = '[]'::jsonb OR s.event_types @> to_jsonb(e.event_type::text)
+			WHERE e.id = ANY($1)
+			ON CONFLICT (event_id, subscription_id) DO NOTHING
+		`, eventIDs, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE outbox_events SET dispatched_at = $1 WHERE id = ANY($2)", now, eventIDs)
+		if err != nil {
+			return err
+		}
+
+		scheduled = len(eventIDs)
+		return nil
+	})
+	return scheduled, err
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]core.WebhookDelivery, error) {
+	var rows []deliveryRow
+	err := r.db.querier(ctx).SelectContext(ctx, &rows, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM outbox_events e, webhook_subscriptions s
+		WHERE d.id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE delivered_at IS NULL AND abandoned_at IS NULL AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		AND e.id = d.event_id AND s.id = d.subscription_id
+		RETURNING d.id, d.attempts,
+			e.id AS event_id, e.event_type, e.payload, e.created_at AS event_created_at,
+			s.id AS subscription_id, s.url, s.secret, s.event_types, s.created_at AS subscription_created_at
+	`, now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		delivery, err := row.toCoreDelivery()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = delivery
+	}
+	return result, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64, attempts int, deliveredAt time.Time) error {
+	_, err := r.db.querier(ctx).ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = $1, delivered_at = $2, last_error = NULL
+		WHERE id = $3
+	`, attempts, deliveredAt.UTC(), id)
+	return err
+}
+
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt *time.Time, lastError string) error {
+	if nextAttemptAt == nil {
+		_, err := r.db.querier(ctx).ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET attempts = $1, abandoned_at = $2, last_error = $3
+			WHERE id = $4
+		`, attempts, time.Now().UTC(), lastError, id)
+		return err
+	}
+
+	_, err := r.db.querier(ctx).ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = $1, next_attempt_at = $2, last_error = $3
+		WHERE id = $4
+	`, attempts, nextAttemptAt.UTC(), lastError, id)
+	return err
+}
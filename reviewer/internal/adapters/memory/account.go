@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+
+	"pr-reviewer/internal/core"
+)
+
+type AccountRepository struct {
+	s *Storage
+}
+
+func NewAccountRepository(storage *Storage) *AccountRepository {
+	return &AccountRepository{s: storage}
+}
+
+func (r *AccountRepository) Link(ctx context.Context, account core.ExternalAccount) error {
+	defer r.s.lock(ctx)()
+
+	key := accountKey{provider: account.Provider, login: account.Login}
+	saveEntry(ctx, r.s.accounts, key, same[string])
+	r.s.accounts[key] = account.UserID
+	return nil
+}
+
+func (r *AccountRepository) ResolveUserID(ctx context.Context, provider, login string) (string, error) {
+	defer r.s.rlock(ctx)()
+
+	userID, ok := r.s.accounts[accountKey{provider: provider, login: login}]
+	if !ok {
+		return "", core.ErrNotFound
+	}
+	return userID, nil
+}
+
+func (r *AccountRepository) LoginsByUserIDs(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	defer r.s.rlock(ctx)()
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	// у пользователя может быть несколько логинов, берём первый по алфавиту
+	result := make(map[string]string, len(userIDs))
+	for key, userID := range r.s.accounts {
+		if key.provider != provider || !wanted[userID] {
+			continue
+		}
+		if login, ok := result[userID]; !ok || key.login < login {
+			result[userID] = key.login
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type AssignmentRepository struct {
+	s *Storage
+}
+
+func NewAssignmentRepository(storage *Storage) *AssignmentRepository {
+	return &AssignmentRepository{s: storage}
+}
+
+func (r *AssignmentRepository) Append(ctx context.Context, assignments ...core.Assignment) error {
+	defer r.s.lock(ctx)()
+
+	for _, assignment := range assignments {
+		if _, ok := r.s.prs[assignment.PullRequestID]; !ok {
+			return core.ErrNotFound
+		}
+		assignment.ID = r.s.nextID()
+		r.s.assignments = append(r.s.assignments, assignment)
+	}
+	return nil
+}
+
+func (r *AssignmentRepository) Close(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	defer r.s.lock(ctx)()
+
+	for i, assignment := range r.s.assignments {
+		if assignment.PullRequestID == prID && assignment.ReviewerID == reviewerID && assignment.UnassignedAt == nil {
+			previous := assignment
+			onRollback(ctx, func() { r.s.assignments[i] = previous })
+			unassignedAt := at
+			r.s.assignments[i].UnassignedAt = &unassignedAt
+		}
+	}
+	return nil
+}
+
+func (r *AssignmentRepository) GetByPullRequestID(ctx context.Context, prID string) ([]core.Assignment, error) {
+	defer r.s.rlock(ctx)()
+
+	// записи добавляются по порядку, поэтому уже отсортированы по времени назначения
+	result := make([]core.Assignment, 0)
+	for _, assignment := range r.s.assignments {
+		if assignment.PullRequestID == prID {
+			result = append(result, assignment)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+
+	"pr-reviewer/internal/core"
+)
+
+type AuditRepository struct {
+	s *Storage
+}
+
+func NewAuditRepository(storage *Storage) *AuditRepository {
+	return &AuditRepository{s: storage}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, record *core.AuditRecord) error {
+	defer r.s.lock(ctx)()
+
+	record.ID = r.s.nextID()
+	r.s.audit = append(r.s.audit, *record)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter core.AuditFilter) ([]core.AuditRecord, error) {
+	defer r.s.rlock(ctx)()
+
+	// записи добавляются с растущими ID, поэтому от новых к старым - с конца
+	result := make([]core.AuditRecord, 0)
+	for i := len(r.s.audit) - 1; i >= 0 && len(result) < filter.Limit; i-- {
+		record := r.s.audit[i]
+		if matchesAudit(record, filter) {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func matchesAudit(record core.AuditRecord, filter core.AuditFilter) bool {
+	if filter.ActorID != "" && record.ActorID != filter.ActorID {
+		return false
+	}
+	if filter.Operation != "" && record.Operation != filter.Operation {
+		return false
+	}
+	if filter.EntityID != "" && record.EntityID != filter.EntityID {
+		return false
+	}
+	if filter.BeforeID != 0 && record.ID >= filter.BeforeID {
+		return false
+	}
+	return inRange(&record.CreatedAt, filter.From, filter.To)
+}
@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+
+	"pr-reviewer/internal/core"
+)
+
+type EventRepository struct {
+	s *Storage
+}
+
+func NewEventRepository(storage *Storage) *EventRepository {
+	return &EventRepository{s: storage}
+}
+
+func (r *EventRepository) Append(ctx context.Context, event core.Event) error {
+	defer r.s.lock(ctx)()
+
+	event.ID = r.s.nextID()
+	event.PullRequest.ReviewersIDs = append([]string{}, event.PullRequest.ReviewersIDs...)
+	if event.Reassignment != nil {
+		reassignment := *event.Reassignment
+		event.Reassignment = &reassignment
+	}
+
+	r.s.events = append(r.s.events, &eventRecord{event: event})
+	return nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+type IdempotencyRepository struct {
+	s *Storage
+}
+
+func NewIdempotencyRepository(storage *Storage) *IdempotencyRepository {
+	return &IdempotencyRepository{s: storage}
+}
+
+func (r *IdempotencyRepository) Create(ctx context.Context, record *core.IdempotencyRecord) error {
+	defer r.s.lock(ctx)()
+
+	for key, stored := range r.s.idempotency {
+		if !stored.ExpiresAt.After(record.CreatedAt) {
+			saveEntry(ctx, r.s.idempotency, key, copyOf[core.IdempotencyRecord])
+			delete(r.s.idempotency, key)
+		}
+	}
+
+	key := idempotencyKey{scope: record.Scope, key: record.Key}
+	if _, ok := r.s.idempotency[key]; ok {
+		return core.ErrIdempotencyKeyExists
+	}
+	stored := *record
+	stored.Response = nil
+	saveEntry(ctx, r.s.idempotency, key, copyOf[core.IdempotencyRecord])
+	r.s.idempotency[key] = &stored
+	return nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, scope, key string) (*core.IdempotencyRecord, error) {
+	defer r.s.rlock(ctx)()
+
+	stored, ok := r.s.idempotency[idempotencyKey{scope: scope, key: key}]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	copied := *stored
+	return &copied, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, response core.IdempotentResponse, expiresAt time.Time) error {
+	defer r.s.lock(ctx)()
+
+	stored, ok := r.s.idempotency[idempotencyKey{scope: scope, key: key}]
+	if !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.idempotency, idempotencyKey{scope: scope, key: key}, copyOf[core.IdempotencyRecord])
+	response.Body = append([]byte(nil), response.Body...)
+	stored.Response = &response
+	stored.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, scope, key string) error {
+	defer r.s.lock(ctx)()
+
+	saveEntry(ctx, r.s.idempotency, idempotencyKey{scope: scope, key: key}, copyOf[core.IdempotencyRecord])
+	delete(r.s.idempotency, idempotencyKey{scope: scope, key: key})
+	return nil
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type teamRecord struct {
+	name     string
+	settings core.TeamSettings
+	rules    []core.OwnershipRule
+}
+
+type prRecord struct {
+	id          string
+	name        string
+	authorID    string
+	status      string
+	required    int
+	createdAt   time.Time
+	mergedAt    *time.Time
+	closedAt    *time.Time
+	reviewerIDs []string
+	reviews     map[string]core.Review
+	override    *core.MergeOverride
+}
+
+type userRecord struct {
+	id             string
+	username       string
+	teamName       string
+	isActive       bool
+	unavailability []core.Unavailability
+}
+
+func (r *userRecord) toCoreUser() *core.User {
+	user := &core.User{
+		ID:       r.id,
+		Username: r.username,
+		TeamName: r.teamName,
+		IsActive: r.isActive,
+	}
+	if len(r.unavailability) > 0 {
+		user.Unavailability = append([]core.Unavailability(nil), r.unavailability...)
+	}
+	return user
+}
+
+func (r *prRecord) toCorePullRequest() *core.PullRequest {
+	reviewerIDs := make([]string, len(r.reviewerIDs))
+	copy(reviewerIDs, r.reviewerIDs)
+
+	reviews := make([]core.Review, len(r.reviewerIDs))
+	for i, reviewerID := range r.reviewerIDs {
+		reviews[i] = r.reviews[reviewerID]
+	}
+
+	pr := &core.PullRequest{
+		ID:                r.id,
+		Name:              r.name,
+		AuthorID:          r.authorID,
+		Status:            core.PullRequestStatus(r.status),
+		ReviewersIDs:      reviewerIDs,
+		Reviews:           reviews,
+		RequiredReviewers: r.required,
+		CreatedAt:         r.createdAt,
+	}
+	if r.mergedAt != nil {
+		mergedAt := *r.mergedAt
+		pr.MergedAt = &mergedAt
+	}
+	if r.override != nil {
+		override := *r.override
+		pr.MergeOverride = &override
+	}
+	return pr
+}
+
+// setReviewers сохраняет ревьюверов PR: у оставшихся сохраняется время назначения,
+// а время обновления меняется только вместе с состоянием.
+func (r *prRecord) setReviewers(pr *core.PullRequest, now time.Time) {
+	reviews := make(map[string]core.Review, len(pr.ReviewersIDs))
+	for _, reviewerID := range pr.ReviewersIDs {
+		review := pr.Review(reviewerID)
+		if existing, ok := r.reviews[reviewerID]; ok {
+			review.AssignedAt = existing.AssignedAt
+			if review.State == existing.State {
+				review.UpdatedAt = existing.UpdatedAt
+			}
+		}
+		if review.AssignedAt.IsZero() {
+			review.AssignedAt = now
+		}
+		if review.UpdatedAt.IsZero() {
+			review.UpdatedAt = now
+		}
+		reviews[reviewerID] = review
+	}
+
+	r.reviewerIDs = make([]string, len(pr.ReviewersIDs))
+	copy(r.reviewerIDs, pr.ReviewersIDs)
+	r.reviews = reviews
+}
+
+func (r *prRecord) clone() *prRecord {
+	copied := *r
+	copied.reviewerIDs = make([]string, len(r.reviewerIDs))
+	copy(copied.reviewerIDs, r.reviewerIDs)
+	copied.reviews = make(map[string]core.Review, len(r.reviews))
+	for reviewerID, review := range r.reviews {
+		copied.reviews[reviewerID] = review
+	}
+	return &copied
+}
+
+type eventRecord struct {
+	event      core.Event
+	dispatched bool
+}
+
+type deliveryRecord struct {
+	id             int64
+	eventID        int64
+	subscriptionID int64
+	attempts       int
+	nextAttemptAt  time.Time
+	delivered      bool
+	abandoned      bool
+	lastError      string
+}
+
+type accountKey struct {
+	provider string
+	login    string
+}
@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type PRRepository struct {
+	s *Storage
+}
+
+func NewPRRepository(storage *Storage) *PRRepository {
+	return &PRRepository{s: storage}
+}
+
+func (r *PRRepository) Create(ctx context.Context, pr *core.PullRequest) error {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.prs[pr.ID]; ok {
+		return core.ErrPRExists
+	}
+	if _, ok := r.s.users[pr.AuthorID]; !ok {
+		return core.ErrNotFound
+	}
+	for _, reviewerID := range pr.ReviewersIDs {
+		if _, ok := r.s.users[reviewerID]; !ok {
+			return core.ErrNotFound
+		}
+	}
+
+	now := time.Now()
+	record := &prRecord{
+		id:        pr.ID,
+		name:      pr.Name,
+		authorID:  pr.AuthorID,
+		status:    string(pr.Status),
+		required:  pr.RequiredReviewers,
+		createdAt: pr.CreatedAt,
+	}
+	if record.createdAt.IsZero() {
+		record.createdAt = now
+	}
+	record.setReviewers(pr, now)
+	if pr.Status == core.PullRequestStatusMerged {
+		record.mergedAt = mergedAt(pr, now)
+	}
+
+	saveEntry(ctx, r.s.prs, pr.ID, (*prRecord).clone)
+	r.s.prs[pr.ID] = record
+	return nil
+}
+
+func (r *PRRepository) GetByID(ctx context.Context, id string) (*core.PullRequest, error) {
+	defer r.s.rlock(ctx)()
+
+	record, ok := r.s.prs[id]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	return record.toCorePullRequest(), nil
+}
+
+func (r *PRRepository) Update(ctx context.Context, pr *core.PullRequest) error {
+	defer r.s.lock(ctx)()
+
+	for _, reviewerID := range pr.ReviewersIDs {
+		if _, ok := r.s.users[reviewerID]; !ok {
+			return core.ErrNotFound
+		}
+	}
+
+	record, ok := r.s.prs[pr.ID]
+	if !ok {
+		return nil
+	}
+
+	saveEntry(ctx, r.s.prs, pr.ID, (*prRecord).clone)
+	record.name = pr.Name
+	record.status = string(pr.Status)
+	switch {
+	case pr.Status != core.PullRequestStatusMerged:
+		record.mergedAt = nil
+	case record.mergedAt == nil:
+		record.mergedAt = mergedAt(pr, time.Now())
+	}
+	switch {
+	case pr.Status != core.PullRequestStatusClosed:
+		record.closedAt = nil
+	case record.closedAt == nil:
+		now := time.Now()
+		record.closedAt = &now
+	}
+	record.setReviewers(pr, time.Now())
+	record.override = nil
+	if pr.MergeOverride != nil {
+		override := *pr.MergeOverride
+		record.override = &override
+	}
+
+	return nil
+}
+
+func (r *PRRepository) GetByReviewerID(ctx context.Context, userID string) ([]*core.PullRequest, error) {
+	defer r.s.rlock(ctx)()
+
+	records := make([]*prRecord, 0)
+	for _, record := range r.s.prs {
+		for _, reviewerID := range record.reviewerIDs {
+			if reviewerID == userID {
+				records = append(records, record)
+				break
+			}
+		}
+	}
+	sortPRRecords(records)
+
+	result := make([]*core.PullRequest, len(records))
+	for i, record := range records {
+		result[i] = record.toCorePullRequest()
+	}
+	return result, nil
+}
+
+func (r *PRRepository) List(ctx context.Context, filter core.PRFilter) ([]*core.PullRequest, error) {
+	defer r.s.rlock(ctx)()
+
+	records := make([]*prRecord, 0)
+	for _, record := range r.s.prs {
+		if r.matches(record, filter) {
+			records = append(records, record)
+		}
+	}
+	sortPRRecords(records)
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	result := make([]*core.PullRequest, len(records))
+	for i, record := range records {
+		result[i] = record.toCorePullRequest()
+	}
+	return result, nil
+}
+
+// matches проверяет PR по фильтру так же, как условие WHERE в db.PRRepository.List.
+func (r *PRRepository) matches(record *prRecord, filter core.PRFilter) bool {
+	if filter.Status != "" && record.status != string(filter.Status) {
+		return false
+	}
+	if filter.AuthorID != "" && record.authorID != filter.AuthorID {
+		return false
+	}
+	if filter.ReviewerID != "" {
+		if _, ok := record.reviews[filter.ReviewerID]; !ok {
+			return false
+		}
+	}
+	if filter.TeamName != "" {
+		author, ok := r.s.users[record.authorID]
+		if !ok || author.teamName != filter.TeamName {
+			return false
+		}
+	}
+	if !inRange(&record.createdAt, filter.CreatedFrom, filter.CreatedTo) {
+		return false
+	}
+	if !inRange(record.mergedAt, filter.MergedFrom, filter.MergedTo) {
+		return false
+	}
+	if after := filter.After; after != nil {
+		if record.createdAt.Before(after.CreatedAt) ||
+			record.createdAt.Equal(after.CreatedAt) && record.id <= after.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange проверяет попадание в [from, to). Отсутствующее время подходит, только если интервал не задан.
+func inRange(at, from, to *time.Time) bool {
+	if at == nil {
+		return from == nil && to == nil
+	}
+	if from != nil && at.Before(*from) {
+		return false
+	}
+	return to == nil || at.Before(*to)
+}
+
+func (r *PRRepository) GetOpenByReviewerIDs(ctx context.Context, userIDs []string) ([]*core.PullRequest, error) {
+	defer r.s.rlock(ctx)()
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		wanted[userID] = true
+	}
+
+	records := make([]*prRecord, 0)
+	for _, record := range r.s.prs {
+		if record.status != string(core.PullRequestStatusOpen) {
+			continue
+		}
+		for _, reviewerID := range record.reviewerIDs {
+			if wanted[reviewerID] {
+				records = append(records, record)
+				break
+			}
+		}
+	}
+	sortPRRecords(records)
+
+	result := make([]*core.PullRequest, len(records))
+	for i, record := range records {
+		result[i] = record.toCorePullRequest()
+	}
+	return result, nil
+}
+
+func (r *PRRepository) GetOverdue(ctx context.Context, now time.Time) ([]*core.PullRequest, error) {
+	defer r.s.rlock(ctx)()
+
+	records := make([]*prRecord, 0)
+	for _, record := range r.s.prs {
+		author, ok := r.s.users[record.authorID]
+		if !ok {
+			continue
+		}
+		team, ok := r.s.teams[author.teamName]
+		if !ok {
+			continue
+		}
+		if len(record.toCorePullRequest().OverdueReviews(team.settings.ReviewSLA, now)) > 0 {
+			records = append(records, record)
+		}
+	}
+	sortPRRecords(records)
+
+	result := make([]*core.PullRequest, len(records))
+	for i, record := range records {
+		result[i] = record.toCorePullRequest()
+	}
+	return result, nil
+}
+
+func (r *PRRepository) CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error) {
+	defer r.s.rlock(ctx)()
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		wanted[userID] = true
+	}
+
+	result := make(map[string]int)
+	for _, record := range r.s.prs {
+		if record.status != string(core.PullRequestStatusOpen) {
+			continue
+		}
+		for _, reviewerID := range record.reviewerIDs {
+			if wanted[reviewerID] {
+				result[reviewerID]++
+			}
+		}
+	}
+	return result, nil
+}
+
+// mergedAt возвращает время merge из PR, а если оно не задано - now.
+func mergedAt(pr *core.PullRequest, now time.Time) *time.Time {
+	if pr.MergedAt != nil {
+		at := *pr.MergedAt
+		return &at
+	}
+	return &now
+}
+
+func sortPRRecords(records []*prRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].createdAt.Equal(records[j].createdAt) {
+			return records[i].id < records[j].id
+		}
+		return records[i].createdAt.Before(records[j].createdAt)
+	})
+}
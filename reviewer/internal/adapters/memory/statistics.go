@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+// prGroup накапливает показатели PR группы, как агрегаты запроса в db.PRRepository.GetStatistics.
+type prGroup struct {
+	open        int
+	merged      int
+	timeToMerge []time.Duration
+}
+
+func (g *prGroup) add(record *prRecord, filter core.StatisticsFilter) {
+	if record.status == string(core.PullRequestStatusOpen) && inRange(&record.createdAt, filter.From, filter.To) {
+		g.open++
+	}
+	if record.mergedAt != nil && inRange(record.mergedAt, filter.From, filter.To) {
+		g.merged++
+		g.timeToMerge = append(g.timeToMerge, record.mergedAt.Sub(record.createdAt))
+	}
+}
+
+func (g *prGroup) empty() bool {
+	return g.open == 0 && g.merged == 0
+}
+
+func (g *prGroup) toCore() core.PRStatistics {
+	return core.PRStatistics{
+		OpenPRs:     g.open,
+		MergedPRs:   g.merged,
+		TimeToMerge: core.NewCycleTime(g.timeToMerge),
+	}
+}
+
+func (r *PRRepository) GetStatistics(ctx context.Context, filter core.StatisticsFilter) (*core.Statistics, error) {
+	defer r.s.rlock(ctx)()
+
+	teamOf := func(record *prRecord) (string, bool) {
+		author, ok := r.s.users[record.authorID]
+		if !ok || filter.TeamName != "" && author.teamName != filter.TeamName {
+			return "", false
+		}
+		return author.teamName, true
+	}
+
+	var total prGroup
+	teams := make(map[string]*prGroup)
+	authors := make(map[string]*prGroup)
+	users := make(map[string]*core.UserStatistics)
+	user := func(userID string) *core.UserStatistics {
+		if users[userID] == nil {
+			users[userID] = &core.UserStatistics{UserID: userID}
+		}
+		return users[userID]
+	}
+	weeks := make(map[time.Time]int)
+	stats := &core.Statistics{Reassignments: make(map[core.AssignmentReason]int)}
+
+	for _, record := range r.s.prs {
+		teamName, ok := teamOf(record)
+		if !ok {
+			continue
+		}
+
+		total.add(record, filter)
+		if teams[teamName] == nil {
+			teams[teamName] = &prGroup{}
+		}
+		teams[teamName].add(record, filter)
+		if authors[record.authorID] == nil {
+			authors[record.authorID] = &prGroup{}
+		}
+		authors[record.authorID].add(record, filter)
+	}
+
+	for _, assignment := range r.s.assignments {
+		if !inRange(&assignment.AssignedAt, filter.From, filter.To) {
+			continue
+		}
+		record, ok := r.s.prs[assignment.PullRequestID]
+		if !ok {
+			continue
+		}
+		if _, ok := teamOf(record); !ok {
+			continue
+		}
+		user(assignment.ReviewerID).Assignments++
+		weeks[weekStart(assignment.AssignedAt)]++
+		stats.Assignments++
+		if assignment.ReplacedReviewerID != "" {
+			user(assignment.ReplacedReviewerID).Reassignments++
+			stats.Reassignments[assignment.Reason]++
+		}
+	}
+
+	stats.PRStatistics = total.toCore()
+	for teamName, group := range teams {
+		if group.empty() {
+			continue
+		}
+		stats.Teams = append(stats.Teams, core.TeamStatistics{TeamName: teamName, PRStatistics: group.toCore()})
+	}
+	for authorID, group := range authors {
+		if group.empty() {
+			continue
+		}
+		user(authorID).PRStatistics = group.toCore()
+	}
+	for _, userStats := range users {
+		stats.Users = append(stats.Users, *userStats)
+	}
+	for start, count := range weeks {
+		stats.AssignmentsPerWeek = append(stats.AssignmentsPerWeek, core.WeeklyAssignments{WeekStart: start, Assignments: count})
+	}
+	return stats, nil
+}
+
+// weekStart возвращает начало недели (понедельник), как date_trunc('week', ...) в PostgreSQL.
+func weekStart(at time.Time) time.Time {
+	year, month, day := at.Date()
+	daysSinceMonday := (int(at.Weekday()) + 6) % 7
+	return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, at.Location())
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"pr-reviewer/internal/core"
+)
+
+// Storage хранит все данные в памяти процесса и повторяет поведение db.DB.
+type Storage struct {
+	mu sync.RWMutex
+
+	teams map[string]*teamRecord
+	users map[string]*userRecord
+	prs   map[string]*prRecord
+
+	events        []*eventRecord
+	subscriptions map[int64]*core.WebhookSubscription
+	deliveries    []*deliveryRecord
+	lastID        int64
+	accounts      map[accountKey]string
+	assignments   []core.Assignment
+	tokens        map[int64]*core.APIToken
+	audit         []core.AuditRecord
+	idempotency   map[idempotencyKey]*core.IdempotencyRecord
+
+	Team        *TeamRepository
+	User        *UserRepository
+	PR          *PRRepository
+	Event       *EventRepository
+	Webhook     *WebhookRepository
+	Account     *AccountRepository
+	Assignment  *AssignmentRepository
+	Token       *TokenRepository
+	Audit       *AuditRepository
+	Idempotency *IdempotencyRepository
+}
+
+func New() *Storage {
+	s := &Storage{
+		teams: make(map[string]*teamRecord),
+		users: make(map[string]*userRecord),
+		prs:   make(map[string]*prRecord),
+
+		subscriptions: make(map[int64]*core.WebhookSubscription),
+		accounts:      make(map[accountKey]string),
+		tokens:        make(map[int64]*core.APIToken),
+		idempotency:   make(map[idempotencyKey]*core.IdempotencyRecord),
+	}
+
+	s.Team = NewTeamRepository(s)
+	s.User = NewUserRepository(s)
+	s.PR = NewPRRepository(s)
+	s.Event = NewEventRepository(s)
+	s.Webhook = NewWebhookRepository(s)
+	s.Account = NewAccountRepository(s)
+	s.Assignment = NewAssignmentRepository(s)
+	s.Token = NewTokenRepository(s)
+	s.Audit = NewAuditRepository(s)
+	s.Idempotency = NewIdempotencyRepository(s)
+
+	return s
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+type txKey struct{}
+
+// tx - состояние транзакции WithinTx. Журналы, которые только дополняются, откатываются
+// к сохранённой длине, остальные изменения - действиями undo в обратном порядке.
+type tx struct {
+	lastID      int64
+	events      int
+	deliveries  int
+	assignments int
+	audit       int
+	undo        []func()
+}
+
+// WithinTx выполняет fn под эксклюзивной блокировкой хранилища.
+// Если fn вернула ошибку, все изменения откатываются к состоянию на момент начала.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &tx{
+		lastID:      s.lastID,
+		events:      len(s.events),
+		deliveries:  len(s.deliveries),
+		assignments: len(s.assignments),
+		audit:       len(s.audit),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.rollback(t)
+			panic(r)
+		}
+		if err != nil {
+			s.rollback(t)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, t))
+}
+
+func (s *Storage) rollback(t *tx) {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	s.lastID = t.lastID
+	s.events = s.events[:t.events]
+	s.deliveries = s.deliveries[:t.deliveries]
+	s.assignments = s.assignments[:t.assignments]
+	s.audit = s.audit[:t.audit]
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*tx)
+	return ok
+}
+
+// onRollback запоминает действие, отменяющее изменение, если оно сделано в транзакции.
+// Вне транзакции изменения сразу окончательные, как и в db.DB.
+func onRollback(ctx context.Context, undo func()) {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.undo = append(t.undo, undo)
+	}
+}
+
+// saveEntry вызывается перед изменением m[key]: при откате запись вернётся к копии,
+// сделанной clone, или будет удалена, если её не было.
+func saveEntry[K comparable, V any](ctx context.Context, m map[K]V, key K, clone func(V) V) {
+	if !inTx(ctx) {
+		return
+	}
+	previous, ok := m[key]
+	if ok {
+		previous = clone(previous)
+	}
+	onRollback(ctx, func() {
+		if ok {
+			m[key] = previous
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+// saveRecord вызывается перед изменением *record на месте.
+func saveRecord[T any](ctx context.Context, record *T) {
+	if !inTx(ctx) {
+		return
+	}
+	previous := *record
+	onRollback(ctx, func() { *record = previous })
+}
+
+func copyOf[T any](value *T) *T {
+	copied := *value
+	return &copied
+}
+
+func same[T any](value T) T {
+	return value
+}
+
+// lock и rlock не блокируют повторно внутри WithinTx: блокировка уже у транзакции.
+func (s *Storage) lock(ctx context.Context) func() {
+	if inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Storage) rlock(ctx context.Context) func() {
+	if inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// nextID выдаёт идентификаторы, аналог BIGSERIAL. Вызывается под блокировкой.
+func (s *Storage) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
@@ -0,0 +1,256 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/core"
+)
+
+func TestNotFound(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	if _, err := storage.Team.GetByName(ctx, "missing"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for team, got %v", err)
+	}
+	if _, err := storage.User.GetByID(ctx, "missing"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for user, got %v", err)
+	}
+	if _, err := storage.PR.GetByID(ctx, "missing"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for PR, got %v", err)
+	}
+}
+
+func TestTeamCreateMovesUsers(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	_ = storage.Team.Create(ctx, &core.Team{Name: "backend", Members: []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: false},
+	}})
+	_ = storage.Team.Create(ctx, &core.Team{Name: "frontend", Members: []core.User{
+		{ID: "u2", Username: "Bob", IsActive: true},
+	}})
+
+	backend, err := storage.Team.GetByName(ctx, "backend")
+	if err != nil {
+		t.Fatalf("failed to get team: %v", err)
+	}
+	if len(backend.Members) != 1 || backend.Members[0].ID != "u1" {
+		t.Errorf("expected only u1 in backend, got %+v", backend.Members)
+	}
+
+	active, err := storage.User.GetActiveByTeamName(ctx, "frontend")
+	if err != nil {
+		t.Fatalf("failed to get active users: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != "u2" || active[0].TeamName != "frontend" {
+		t.Errorf("expected u2 to be active in frontend, got %+v", active)
+	}
+}
+
+func TestGetStatistics(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	_ = storage.Team.Create(ctx, &core.Team{Name: "backend", Members: []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+		{ID: "u3", Username: "Charlie", IsActive: true},
+	}})
+
+	prs := []*core.PullRequest{
+		{ID: "pr-1", Name: "One", AuthorID: "u1", Status: core.PullRequestStatusOpen, ReviewersIDs: []string{"u2", "u3"}},
+		{ID: "pr-2", Name: "Two", AuthorID: "u1", Status: core.PullRequestStatusOpen, ReviewersIDs: []string{"u2"}},
+	}
+	for _, pr := range prs {
+		if err := storage.PR.Create(ctx, pr); err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+	}
+	// u3 снят с pr-2 после назначения: назначение считается по истории
+	assignedAt := time.Now()
+	err := storage.Assignment.Append(ctx,
+		core.Assignment{PullRequestID: "pr-1", ReviewerID: "u2", AssignedAt: assignedAt, Reason: core.AssignmentReasonInitial},
+		core.Assignment{PullRequestID: "pr-1", ReviewerID: "u3", AssignedAt: assignedAt, Reason: core.AssignmentReasonInitial},
+		core.Assignment{PullRequestID: "pr-2", ReviewerID: "u3", AssignedAt: assignedAt, Reason: core.AssignmentReasonInitial},
+		core.Assignment{PullRequestID: "pr-2", ReviewerID: "u2", AssignedAt: assignedAt, Reason: core.AssignmentReasonReassign, ReplacedReviewerID: "u3"},
+	)
+	if err != nil {
+		t.Fatalf("failed to append assignments: %v", err)
+	}
+
+	stats, err := storage.PR.GetStatistics(ctx, core.StatisticsFilter{})
+	if err != nil {
+		t.Fatalf("failed to get statistics: %v", err)
+	}
+	assignments := make(map[string]int)
+	for _, user := range stats.Users {
+		if user.Assignments > 0 {
+			assignments[user.UserID] = user.Assignments
+		}
+	}
+	if assignments["u2"] != 2 || assignments["u3"] != 2 || len(assignments) != 2 || stats.Assignments != 4 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if stats.OpenPRs != 2 || len(stats.Teams) != 1 || stats.Teams[0].OpenPRs != 2 {
+		t.Errorf("expected 2 open PRs in backend, got %+v", stats)
+	}
+	if stats.Reassignments[core.AssignmentReasonReassign] != 1 {
+		t.Errorf("expected one reassignment, got %+v", stats.Reassignments)
+	}
+}
+
+func TestPRUpdateKeepsTimestamps(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	_ = storage.Team.Create(ctx, &core.Team{Name: "backend", Members: []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+	}})
+
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	pr := &core.PullRequest{
+		ID: "pr-1", Name: "One", AuthorID: "u1", Status: core.PullRequestStatusOpen,
+		ReviewersIDs: []string{"u2"}, CreatedAt: createdAt,
+	}
+	if err := storage.PR.Create(ctx, pr); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	mergedAt := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	pr.Status = core.PullRequestStatusMerged
+	pr.MergedAt = &mergedAt
+	if err := storage.PR.Update(ctx, pr); err != nil {
+		t.Fatalf("failed to update PR: %v", err)
+	}
+
+	// повторное обновление смерженного PR не сдвигает время merge
+	pr.MergedAt = nil
+	pr.Name = "Renamed"
+	if err := storage.PR.Update(ctx, pr); err != nil {
+		t.Fatalf("failed to update PR: %v", err)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if !stored.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected created_at %v, got %v", createdAt, stored.CreatedAt)
+	}
+	if stored.MergedAt == nil || !stored.MergedAt.Equal(mergedAt) {
+		t.Errorf("expected merged_at %v, got %v", mergedAt, stored.MergedAt)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	_ = storage.Team.Create(ctx, &core.Team{Name: "backend", Members: []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+	}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pr := &core.PullRequest{
+				ID:           fmt.Sprintf("pr-%d", i),
+				Name:         "Feature",
+				AuthorID:     "u1",
+				Status:       core.PullRequestStatusOpen,
+				ReviewersIDs: []string{"u2"},
+			}
+			if err := storage.PR.Create(ctx, pr); err != nil {
+				t.Errorf("failed to create PR: %v", err)
+			}
+			_, _ = storage.PR.GetByReviewerID(ctx, "u2")
+			_, _ = storage.User.GetActiveByTeamName(ctx, "backend")
+		}(i)
+	}
+	wg.Wait()
+
+	prs, err := storage.PR.GetByReviewerID(ctx, "u2")
+	if err != nil {
+		t.Fatalf("failed to get PRs: %v", err)
+	}
+	if len(prs) != 50 {
+		t.Errorf("expected 50 PRs, got %d", len(prs))
+	}
+}
+
+func TestWithinTxRollback(t *testing.T) {
+	storage := memory.New()
+	ctx := context.Background()
+
+	_ = storage.Team.Create(ctx, &core.Team{Name: "backend", Members: []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+	}})
+	pr := &core.PullRequest{
+		ID: "pr-1", Name: "One", AuthorID: "u1", Status: core.PullRequestStatusOpen, ReviewersIDs: []string{"u2"},
+	}
+	if err := storage.PR.Create(ctx, pr); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	assignment := core.Assignment{PullRequestID: "pr-1", ReviewerID: "u2", AssignedAt: time.Now(), Reason: core.AssignmentReasonInitial}
+	if err := storage.Assignment.Append(ctx, assignment); err != nil {
+		t.Fatalf("failed to append assignment: %v", err)
+	}
+
+	errRollback := errors.New("rollback")
+	err := storage.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := storage.User.SetActiveByTeam(ctx, "backend", []string{"u2"}, false); err != nil {
+			return err
+		}
+		merged := *pr
+		merged.Status = core.PullRequestStatusMerged
+		if err := storage.PR.Update(ctx, &merged); err != nil {
+			return err
+		}
+		if err := storage.Assignment.Close(ctx, "pr-1", "u2", time.Now()); err != nil {
+			return err
+		}
+		if err := storage.Assignment.Append(ctx, assignment); err != nil {
+			return err
+		}
+		if err := storage.Audit.Append(ctx, &core.AuditRecord{Operation: core.AuditOperationMergePR, EntityID: "pr-1"}); err != nil {
+			return err
+		}
+		if err := storage.Team.Create(ctx, &core.Team{Name: "frontend"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+
+	if user, _ := storage.User.GetByID(ctx, "u2"); user == nil || !user.IsActive {
+		t.Errorf("expected u2 to stay active, got %+v", user)
+	}
+	if got, _ := storage.PR.GetByID(ctx, "pr-1"); got == nil || got.Status != core.PullRequestStatusOpen || got.MergedAt != nil {
+		t.Errorf("expected PR to stay open, got %+v", got)
+	}
+	if history, _ := storage.Assignment.GetByPullRequestID(ctx, "pr-1"); len(history) != 1 || history[0].UnassignedAt != nil {
+		t.Errorf("expected one open assignment, got %+v", history)
+	}
+	if records, _ := storage.Audit.List(ctx, core.AuditFilter{Limit: 10}); len(records) != 0 {
+		t.Errorf("expected no audit records, got %+v", records)
+	}
+	if _, err := storage.Team.GetByName(ctx, "frontend"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected frontend to be rolled back, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"pr-reviewer/internal/core"
+)
+
+type TeamRepository struct {
+	s *Storage
+}
+
+func NewTeamRepository(storage *Storage) *TeamRepository {
+	return &TeamRepository{s: storage}
+}
+
+func (r *TeamRepository) Create(ctx context.Context, team *core.Team) error {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.teams[team.Name]; !ok {
+		saveEntry(ctx, r.s.teams, team.Name, copyOf[teamRecord])
+		r.s.teams[team.Name] = &teamRecord{name: team.Name, settings: team.Settings}
+	}
+
+	for _, member := range team.Members {
+		record := &userRecord{
+			id:       member.ID,
+			username: member.Username,
+			teamName: team.Name,
+			isActive: member.IsActive,
+		}
+		// периоды отсутствия хранятся отдельно от пользователя и при upsert не меняются
+		if existing, ok := r.s.users[member.ID]; ok {
+			record.unavailability = existing.unavailability
+		}
+		saveEntry(ctx, r.s.users, member.ID, copyOf[userRecord])
+		r.s.users[member.ID] = record
+	}
+
+	return nil
+}
+
+func (r *TeamRepository) GetByName(ctx context.Context, name string) (*core.Team, error) {
+	defer r.s.rlock(ctx)()
+
+	team, ok := r.s.teams[name]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+
+	members := make([]core.User, 0)
+	for _, user := range r.s.users {
+		if user.teamName == name {
+			members = append(members, *user.toCoreUser())
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	settings := team.settings
+	settings.FallbackTeams = append([]string(nil), team.settings.FallbackTeams...)
+	return &core.Team{
+		Name:           team.name,
+		Members:        members,
+		Settings:       settings,
+		OwnershipRules: append([]core.OwnershipRule(nil), team.rules...),
+	}, nil
+}
+
+func (r *TeamRepository) UpdateSettings(ctx context.Context, name string, settings core.TeamSettings) error {
+	defer r.s.lock(ctx)()
+
+	team, ok := r.s.teams[name]
+	if !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.teams, name, copyOf[teamRecord])
+	team.settings = settings
+	return nil
+}
+
+func (r *TeamRepository) UpdateOwnershipRules(ctx context.Context, name string, rules []core.OwnershipRule) error {
+	defer r.s.lock(ctx)()
+
+	team, ok := r.s.teams[name]
+	if !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.teams, name, copyOf[teamRecord])
+	team.rules = append([]core.OwnershipRule(nil), rules...)
+	return nil
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type TokenRepository struct {
+	s *Storage
+}
+
+func NewTokenRepository(storage *Storage) *TokenRepository {
+	return &TokenRepository{s: storage}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, token *core.APIToken) error {
+	defer r.s.lock(ctx)()
+
+	token.ID = r.s.nextID()
+	token.CreatedAt = time.Now()
+
+	stored := *token
+	saveEntry(ctx, r.s.tokens, stored.ID, copyOf[core.APIToken])
+	r.s.tokens[stored.ID] = &stored
+	return nil
+}
+
+func (r *TokenRepository) GetByHash(ctx context.Context, hash string) (*core.APIToken, error) {
+	defer r.s.rlock(ctx)()
+
+	for _, token := range r.s.tokens {
+		if token.Hash == hash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, core.ErrNotFound
+}
+
+func (r *TokenRepository) List(ctx context.Context) ([]core.APIToken, error) {
+	defer r.s.rlock(ctx)()
+
+	result := make([]core.APIToken, 0, len(r.s.tokens))
+	for _, token := range r.s.tokens {
+		result = append(result, *token)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *TokenRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	defer r.s.lock(ctx)()
+
+	token, ok := r.s.tokens[id]
+	if !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.tokens, id, copyOf[core.APIToken])
+	if token.RevokedAt == nil {
+		token.RevokedAt = &at
+	}
+	return nil
+}
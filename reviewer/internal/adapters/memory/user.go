@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"pr-reviewer/internal/core"
+)
+
+type UserRepository struct {
+	s *Storage
+}
+
+func NewUserRepository(storage *Storage) *UserRepository {
+	return &UserRepository{s: storage}
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*core.User, error) {
+	defer r.s.rlock(ctx)()
+
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	return user.toCoreUser(), nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *core.User) error {
+	defer r.s.lock(ctx)()
+
+	// как и UPDATE в postgres, отсутствие строки ошибкой не считается
+	existing, ok := r.s.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	saveEntry(ctx, r.s.users, user.ID, copyOf[userRecord])
+	existing.username = user.Username
+	existing.teamName = user.TeamName
+	existing.isActive = user.IsActive
+	return nil
+}
+
+func (r *UserRepository) GetActiveByTeamName(ctx context.Context, teamName string) ([]*core.User, error) {
+	defer r.s.rlock(ctx)()
+
+	result := make([]*core.User, 0)
+	for _, user := range r.s.users {
+		if user.teamName != teamName {
+			continue
+		}
+		if candidate := user.toCoreUser(); candidate.CanBeReviewer() {
+			result = append(result, candidate)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *UserRepository) SetActiveByTeam(ctx context.Context, teamName string, userIDs []string, isActive bool) ([]*core.User, error) {
+	defer r.s.lock(ctx)()
+
+	updated := make(map[string]bool, len(userIDs))
+	result := make([]*core.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		user, ok := r.s.users[id]
+		if !ok || user.teamName != teamName || updated[id] {
+			continue
+		}
+		saveEntry(ctx, r.s.users, id, copyOf[userRecord])
+		user.isActive = isActive
+		updated[id] = true
+		result = append(result, user.toCoreUser())
+	}
+	return result, nil
+}
+
+func (r *UserRepository) SetUnavailability(ctx context.Context, userID string, windows []core.Unavailability) error {
+	defer r.s.lock(ctx)()
+
+	user, ok := r.s.users[userID]
+	if !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.users, userID, copyOf[userRecord])
+	user.unavailability = append([]core.Unavailability(nil), windows...)
+	return nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type WebhookRepository struct {
+	s *Storage
+}
+
+func NewWebhookRepository(storage *Storage) *WebhookRepository {
+	return &WebhookRepository{s: storage}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *core.WebhookSubscription) error {
+	defer r.s.lock(ctx)()
+
+	subscription.ID = r.s.nextID()
+	subscription.CreatedAt = time.Now()
+
+	stored := *subscription
+	stored.EventTypes = append([]core.EventType{}, subscription.EventTypes...)
+	saveEntry(ctx, r.s.subscriptions, stored.ID, copyOf[core.WebhookSubscription])
+	r.s.subscriptions[stored.ID] = &stored
+	return nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]core.WebhookSubscription, error) {
+	defer r.s.rlock(ctx)()
+
+	result := make([]core.WebhookSubscription, 0, len(r.s.subscriptions))
+	for _, subscription := range r.s.subscriptions {
+		copied := *subscription
+		copied.EventTypes = append([]core.EventType{}, subscription.EventTypes...)
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.subscriptions[id]; !ok {
+		return core.ErrNotFound
+	}
+	saveEntry(ctx, r.s.subscriptions, id, copyOf[core.WebhookSubscription])
+	delete(r.s.subscriptions, id)
+
+	// как ON DELETE CASCADE; прежний срез не меняется, чтобы откат мог его вернуть
+	previous := r.s.deliveries
+	onRollback(ctx, func() { r.s.deliveries = previous })
+	deliveries := make([]*deliveryRecord, 0, len(r.s.deliveries))
+	for _, delivery := range r.s.deliveries {
+		if delivery.subscriptionID != id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	r.s.deliveries = deliveries
+	return nil
+}
+
+func (r *WebhookRepository) ScheduleDeliveries(ctx context.Context, limit int) (int, error) {
+	defer r.s.lock(ctx)()
+
+	now := time.Now()
+	scheduled := 0
+	for _, record := range r.s.events {
+		if scheduled >= limit {
+			break
+		}
+		if record.dispatched {
+			continue
+		}
+
+		for _, subscription := range r.s.subscriptions {
+			if subscription.Wants(record.event.Type) {
+				r.s.deliveries = append(r.s.deliveries, &deliveryRecord{
+					id:             r.s.nextID(),
+					eventID:        record.event.ID,
+					subscriptionID: subscription.ID,
+					nextAttemptAt:  now,
+				})
+			}
+		}
+		saveRecord(ctx, record)
+		record.dispatched = true
+		scheduled++
+	}
+	return scheduled, nil
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]core.WebhookDelivery, error) {
+	defer r.s.lock(ctx)()
+
+	events := make(map[int64]core.Event, len(r.s.events))
+	for _, record := range r.s.events {
+		events[record.event.ID] = record.event
+	}
+
+	due := make([]*deliveryRecord, 0)
+	for _, delivery := range r.s.deliveries {
+		if !delivery.delivered && !delivery.abandoned && !delivery.nextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].nextAttemptAt.Before(due[j].nextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	result := make([]core.WebhookDelivery, len(due))
+	for i, delivery := range due {
+		saveRecord(ctx, delivery)
+		delivery.nextAttemptAt = now.Add(lease)
+
+		event := events[delivery.eventID]
+		event.PullRequest.ReviewersIDs = append([]string{}, event.PullRequest.ReviewersIDs...)
+		subscription := *r.s.subscriptions[delivery.subscriptionID]
+		subscription.EventTypes = append([]core.EventType{}, subscription.EventTypes...)
+
+		result[i] = core.WebhookDelivery{
+			ID:           delivery.id,
+			Event:        event,
+			Subscription: subscription,
+			Attempts:     delivery.attempts,
+		}
+	}
+	return result, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64, attempts int, _ time.Time) error {
+	defer r.s.lock(ctx)()
+
+	if delivery := r.s.findDelivery(id); delivery != nil {
+		saveRecord(ctx, delivery)
+		delivery.attempts = attempts
+		delivery.delivered = true
+		delivery.lastError = ""
+	}
+	return nil
+}
+
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt *time.Time, lastError string) error {
+	defer r.s.lock(ctx)()
+
+	if delivery := r.s.findDelivery(id); delivery != nil {
+		saveRecord(ctx, delivery)
+		delivery.attempts = attempts
+		delivery.lastError = lastError
+		if nextAttemptAt == nil {
+			delivery.abandoned = true
+		} else {
+			delivery.nextAttemptAt = *nextAttemptAt
+		}
+	}
+	return nil
+}
+
+func (s *Storage) findDelivery(id int64) *deliveryRecord {
+	for _, delivery := range s.deliveries {
+		if delivery.id == id {
+			return delivery
+		}
+	}
+	return nil
+}
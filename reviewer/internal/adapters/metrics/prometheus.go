@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"pr-reviewer/internal/core"
+)
+
+const namespace = "pr_reviewer"
+
+// latencyBuckets в секундах; граница 0.3 соответствует SLI времени ответа.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.5, 1, 2.5, 5}
+
+// minReviewers - число ревьюверов, меньше которого PR считается недоукомплектованным.
+const minReviewers = 2
+
+// Prometheus собирает метрики HTTP-запросов и доменные счётчики сервиса в собственный
+// реестр и отдаёт их в текстовом формате Prometheus.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+
+	prsCreated      prometheus.Counter
+	prsUnderstaffed prometheus.Counter
+	prsMerged       prometheus.Counter
+	reassignments   *prometheus.CounterVec
+	noCandidate     prometheus.Counter
+}
+
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   latencyBuckets,
+		}, []string{"method", "route", "status"}),
+		prsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "prs_created_total",
+			Help:      "Pull requests created.",
+		}),
+		prsUnderstaffed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "prs_created_understaffed_total",
+			Help:      "Pull requests created with fewer than two reviewers.",
+		}),
+		prsMerged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "prs_merged_total",
+			Help:      "Pull requests merged.",
+		}),
+		reassignments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reviewer_reassignments_total",
+			Help:      "Reviewer replacements by reason.",
+		}, []string{"reason"}),
+		noCandidate: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "no_candidate_total",
+			Help:      "Reviewer replacements that found no active candidate.",
+		}),
+	}
+
+	p.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		p.requests, p.latency,
+		p.prsCreated, p.prsUnderstaffed, p.prsMerged, p.reassignments, p.noCandidate,
+	)
+	return p
+}
+
+// Handler отдаёт метрики для GET /metrics.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func (p *Prometheus) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	p.requests.WithLabelValues(method, route, code).Inc()
+	p.latency.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+func (p *Prometheus) PRCreated(reviewers int) {
+	p.prsCreated.Inc()
+	if reviewers < minReviewers {
+		p.prsUnderstaffed.Inc()
+	}
+}
+
+func (p *Prometheus) PRMerged() {
+	p.prsMerged.Inc()
+}
+
+func (p *Prometheus) ReviewerReassigned(reason core.AssignmentReason) {
+	p.reassignments.WithLabelValues(string(reason)).Inc()
+}
+
+func (p *Prometheus) NoCandidate() {
+	p.noCandidate.Inc()
+}
@@ -0,0 +1,117 @@
+package metrics_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/adapters/metrics"
+	"pr-reviewer/internal/adapters/rest"
+	"pr-reviewer/internal/core"
+)
+
+func TestPrometheus_ExposesHTTPAndDomainMetrics(t *testing.T) {
+	prometheus := metrics.NewPrometheus()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.Handle("GET /metrics", prometheus.Handler())
+	handler := rest.MetricsMiddleware(prometheus)(mux)
+
+	for _, target := range []string{"/pullRequest/get?pull_request_id=pr-1", "/pullRequest/get?pull_request_id=pr-2", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	prometheus.PRCreated(2)
+	prometheus.PRCreated(1)
+	prometheus.PRMerged()
+	prometheus.ReviewerReassigned(core.AssignmentReasonDeactivation)
+	prometheus.NoCandidate()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+
+	for _, want := range []string{
+		`pr_reviewer_http_requests_total{method="GET",route="/pullRequest/get",status="404"} 2`,
+		`pr_reviewer_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`pr_reviewer_http_request_duration_seconds_bucket{method="GET",route="/pullRequest/get",status="404",le="0.3"} 2`,
+		`pr_reviewer_prs_created_total 2`,
+		`pr_reviewer_prs_created_understaffed_total 1`,
+		`pr_reviewer_prs_merged_total 1`,
+		`pr_reviewer_reviewer_reassignments_total{reason="deactivation"} 1`,
+		`pr_reviewer_no_candidate_total 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
+
+func TestPrometheus_CountsRejectedRequests(t *testing.T) {
+	prometheus := metrics.NewPrometheus()
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithTokens(storage.Token))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /team/get", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("GET /metrics", prometheus.Handler())
+	handler := rest.AuthMiddleware(logger, service, "/metrics")(mux)
+	handler = rest.MetricsMiddleware(prometheus)(rest.RouteMiddleware(mux)(handler))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/team/get?team_name=backend", nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	want := `pr_reviewer_http_requests_total{method="GET",route="/team/get",status="401"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("expected metrics to contain %q", want)
+	}
+}
+
+func TestPrometheus_CountsIdempotentReplays(t *testing.T) {
+	prometheus := metrics.NewPrometheus()
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithIdempotency(storage.Idempotency, time.Hour, time.Minute))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /team/add", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.Handle("GET /metrics", prometheus.Handler())
+	handler := rest.IdempotencyMiddleware(logger, service)(mux)
+	handler = rest.MetricsMiddleware(prometheus)(rest.RouteMiddleware(mux)(handler))
+
+	for _, body := range []string{`{"team_name":"backend"}`, `{"team_name":"backend"}`, `{"team_name":"frontend"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(body))
+		req.Header.Set(rest.IdempotencyHeader, "team-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	for _, want := range []string{
+		`pr_reviewer_http_requests_total{method="POST",route="/team/add",status="201"} 2`,
+		`pr_reviewer_http_requests_total{method="POST",route="/team/add",status="409"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
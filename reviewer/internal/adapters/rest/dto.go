@@ -1,5 +1,7 @@
 package rest
 
+import "encoding/json"
+
 type TeamMemberDTO struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -7,25 +9,85 @@ type TeamMemberDTO struct {
 }
 
 type TeamDTO struct {
-	TeamName string          `json:"team_name"`
-	Members  []TeamMemberDTO `json:"members"`
+	TeamName          string          `json:"team_name"`
+	Members           []TeamMemberDTO `json:"members"`
+	ReviewerStrategy  string          `json:"reviewer_strategy,omitempty"`
+	RequiredReviewers int             `json:"required_reviewers"` // 0 при создании означает значение по умолчанию
+	RequiredApprovals int             `json:"required_approvals"` // 0 отключает проверку одобрений при merge
+	FallbackTeams     []string        `json:"fallback_teams,omitempty"`
+	AutoReassign      bool            `json:"auto_reassign"`
+	// длительность в формате Go, например "24h"; пустая строка отключает SLA
+	ReviewSLA string `json:"review_sla,omitempty"`
+	// задаются через /team/setCodeOwners и в /team/add игнорируются
+	CodeOwners []OwnershipRuleDTO `json:"code_owners,omitempty"`
+}
+
+type OwnershipRuleDTO struct {
+	Pattern string   `json:"pattern"`
+	Users   []string `json:"users,omitempty"`
+	Teams   []string `json:"teams,omitempty"`
+}
+
+type SetCodeOwnersDTO struct {
+	TeamName string             `json:"team_name"`
+	Rules    []OwnershipRuleDTO `json:"rules"`
+}
+
+type UpdateTeamSettingsDTO struct {
+	TeamName          string    `json:"team_name"`
+	ReviewerStrategy  *string   `json:"reviewer_strategy,omitempty"`
+	RequiredReviewers *int      `json:"required_reviewers,omitempty"`
+	RequiredApprovals *int      `json:"required_approvals,omitempty"`
+	FallbackTeams     *[]string `json:"fallback_teams,omitempty"`
+	AutoReassign      *bool     `json:"auto_reassign,omitempty"`
+	ReviewSLA         *string   `json:"review_sla,omitempty"`
 }
 
 type UserDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string              `json:"user_id"`
+	Username       string              `json:"username"`
+	TeamName       string              `json:"team_name"`
+	IsActive       bool                `json:"is_active"`
+	Unavailability []UnavailabilityDTO `json:"unavailability,omitempty"`
+}
+
+// UnavailabilityDTO - период отсутствия [from, to) в RFC 3339.
+type UnavailabilityDTO struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 
 type PullRequestDTO struct {
-	PullRequestID     string   `json:"pull_request_id"`
-	PullRequestName   string   `json:"pull_request_name"`
-	AuthorID          string   `json:"author_id"`
-	Status            string   `json:"status"`
-	AssignedReviewers []string `json:"assigned_reviewers"`
-	CreatedAt         *string  `json:"createdAt,omitempty"`
-	MergedAt          *string  `json:"mergedAt,omitempty"`
+	PullRequestID     string            `json:"pull_request_id"`
+	PullRequestName   string            `json:"pull_request_name"`
+	AuthorID          string            `json:"author_id"`
+	Status            string            `json:"status"`
+	AssignedReviewers []string          `json:"assigned_reviewers"`
+	RequiredReviewers int               `json:"required_reviewers"`
+	MissingReviewers  int               `json:"missing_reviewers,omitempty"`
+	Reviews           []ReviewDTO       `json:"reviews"`
+	MergeOverride     *MergeOverrideDTO `json:"merge_override,omitempty"`
+	CreatedAt         *string           `json:"createdAt,omitempty"`
+	MergedAt          *string           `json:"mergedAt,omitempty"`
+}
+
+type ListPRsResponseDTO struct {
+	PullRequests []PullRequestDTO `json:"pull_requests"`
+	// курсор следующей страницы, отсутствует на последней
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ReviewDTO struct {
+	ReviewerID string `json:"reviewer_id"`
+	State      string `json:"state"`
+	AssignedAt string `json:"assigned_at"`
+	UpdatedAt  string `json:"updated_at"`
+	// запасная команда, из которой назначен ревьювер
+	FallbackTeam string `json:"fallback_team,omitempty"`
+	// шаблон правила владения кодом, по которому назначен ревьювер
+	OwnerPattern string `json:"owner_pattern,omitempty"`
+	// время эскалации ревью, просроченного относительно SLA команды
+	EscalatedAt *string `json:"escalated_at,omitempty"`
 }
 
 type PullRequestShortDTO struct {
@@ -33,6 +95,63 @@ type PullRequestShortDTO struct {
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
 	Status          string `json:"status"`
+	// состояние ревью пользователя, для которого запрошен список
+	ReviewState string  `json:"review_state,omitempty"`
+	CreatedAt   *string `json:"createdAt,omitempty"`
+	MergedAt    *string `json:"mergedAt,omitempty"`
+}
+
+// OverduePRDTO - открытый PR с ревью, просроченными относительно SLA команды автора.
+type OverduePRDTO struct {
+	PullRequestShortDTO
+	ReviewSLA        string             `json:"review_sla"`
+	OverdueReviewers []OverdueReviewDTO `json:"overdue_reviewers"`
+}
+
+type OverdueReviewDTO struct {
+	ReviewerID  string  `json:"reviewer_id"`
+	AssignedAt  string  `json:"assigned_at"`
+	DueAt       string  `json:"due_at"`
+	EscalatedAt *string `json:"escalated_at"`
+}
+
+type GetOverduePRsResponseDTO struct {
+	PullRequests []OverduePRDTO `json:"pull_requests"`
+}
+
+// AssignmentDTO - запись истории назначений, unassigned_at = null, пока ревьювер назначен.
+type AssignmentDTO struct {
+	ReviewerID         string  `json:"reviewer_id"`
+	AssignedAt         string  `json:"assigned_at"`
+	UnassignedAt       *string `json:"unassigned_at"`
+	Reason             string  `json:"reason"`
+	ReplacedReviewerID string  `json:"replaced_reviewer_id,omitempty"`
+	ActorID            string  `json:"actor_id,omitempty"`
+}
+
+type GetPRHistoryResponseDTO struct {
+	PullRequestID string          `json:"pull_request_id"`
+	History       []AssignmentDTO `json:"history"`
+}
+
+type DeactivateTeamUsersDTO struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids"`
+}
+
+type ReassignmentDTO struct {
+	PullRequestID string  `json:"pull_request_id"`
+	OldReviewerID string  `json:"old_reviewer_id"`
+	NewReviewerID *string `json:"new_reviewer_id"`
+	FallbackTeam  string  `json:"fallback_team,omitempty"`
+	// заполняется, если замену найти не удалось и ревьювер остался назначен
+	Error *ErrorDetail `json:"error,omitempty"`
+}
+
+type DeactivateTeamUsersResponseDTO struct {
+	TeamName      string            `json:"team_name"`
+	Users         []UserDTO         `json:"users"`
+	Reassignments []ReassignmentDTO `json:"reassignments"`
 }
 
 type SetUserActiveDTO struct {
@@ -40,14 +159,49 @@ type SetUserActiveDTO struct {
 	IsActive bool   `json:"is_active"`
 }
 
+type SetUnavailabilityDTO struct {
+	UserID  string              `json:"user_id"`
+	Windows []UnavailabilityDTO `json:"windows"`
+}
+
 type CreatePRDTO struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	ChangedFiles    []string `json:"changed_files,omitempty"`
+}
+
+type MergeOverrideDTO struct {
+	ActorID string `json:"actor_id"`
+	Reason  string `json:"reason"`
 }
 
 type MergePRDTO struct {
 	PullRequestID string `json:"pull_request_id"`
+	// force пропускает политику merge команды, reason тогда обязателен. Автор обхода - пользователь
+	// токена или X-Actor-ID; actor_id нужен только без них и не может им противоречить
+	Force   bool   `json:"force,omitempty"`
+	ActorID string `json:"actor_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type ClosePRDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
+type SubmitReviewDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	State         string `json:"state"`
+}
+
+type ReopenPRDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
+type ReopenPRResponseDTO struct {
+	PR            PullRequestDTO    `json:"pr"`
+	Reassignments []ReassignmentDTO `json:"reassignments"`
 }
 
 type ReassignReviewerDTO struct {
@@ -65,12 +219,129 @@ type GetUserReviewsResponseDTO struct {
 	PullRequests []PullRequestShortDTO `json:"pull_requests"`
 }
 
+// CycleTimeDTO - время от создания PR до merge в секундах.
+type CycleTimeDTO struct {
+	Count          int     `json:"count"`
+	AverageSeconds float64 `json:"avg_seconds"`
+	P50Seconds     float64 `json:"p50_seconds"`
+	P90Seconds     float64 `json:"p90_seconds"`
+}
+
 type UserStatisticDTO struct {
-	UserID           string `json:"user_id"`
+	UserID             string       `json:"user_id"`
+	AssignmentsCount   int          `json:"assignments_count"`
+	ReassignmentsCount int          `json:"reassignments_count"`
+	OpenPRs            int          `json:"open_prs"`
+	MergedPRs          int          `json:"merged_prs"`
+	TimeToMerge        CycleTimeDTO `json:"time_to_merge"`
+}
+
+type TeamStatisticDTO struct {
+	TeamName    string       `json:"team_name"`
+	OpenPRs     int          `json:"open_prs"`
+	MergedPRs   int          `json:"merged_prs"`
+	TimeToMerge CycleTimeDTO `json:"time_to_merge"`
+}
+
+type WeeklyAssignmentsDTO struct {
+	// понедельник недели, YYYY-MM-DD
+	WeekStart        string `json:"week_start"`
 	AssignmentsCount int    `json:"assignments_count"`
 }
 
 type StatisticsResponseDTO struct {
-	ByUsers          []UserStatisticDTO `json:"by_users"`
-	TotalAssignments int                `json:"total_assignments"`
+	ByUsers               []UserStatisticDTO     `json:"by_users"`
+	ByTeams               []TeamStatisticDTO     `json:"by_teams"`
+	AssignmentsPerWeek    []WeeklyAssignmentsDTO `json:"assignments_per_week"`
+	TotalAssignments      int                    `json:"total_assignments"`
+	TotalReassignments    int                    `json:"total_reassignments"`
+	ReassignmentsByReason map[string]int         `json:"reassignments_by_reason"`
+	OpenPRs               int                    `json:"open_prs"`
+	MergedPRs             int                    `json:"merged_prs"`
+	TimeToMerge           CycleTimeDTO           `json:"time_to_merge"`
+}
+
+type CreateWebhookDTO struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+// WebhookDTO - подписка без секрета: он известен только создателю.
+type WebhookDTO struct {
+	WebhookID  int64    `json:"webhook_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type DeleteWebhookDTO struct {
+	WebhookID int64 `json:"webhook_id"`
+}
+
+type ExternalAccountDTO struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+// ExternalReviewerDTO - ревьювер с логином во внешней системе, login = null, если связи нет.
+type ExternalReviewerDTO struct {
+	UserID string  `json:"user_id"`
+	Login  *string `json:"login"`
+}
+
+// IntegrationResponseDTO - ответ на входящий вебхук внешней системы.
+type IntegrationResponseDTO struct {
+	Status    string                `json:"status"`
+	Reason    string                `json:"reason,omitempty"`
+	PR        *PullRequestDTO       `json:"pr,omitempty"`
+	Reviewers []ExternalReviewerDTO `json:"reviewers,omitempty"`
+}
+
+type CreateTokenDTO struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+// TokenDTO - описание токена без самого токена и его хэша.
+type TokenDTO struct {
+	TokenID   int64   `json:"token_id"`
+	Name      string  `json:"name"`
+	Role      string  `json:"role"`
+	UserID    string  `json:"user_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	RevokedAt *string `json:"revoked_at"`
+}
+
+// CreateTokenResponseDTO - token возвращается только при создании.
+type CreateTokenResponseDTO struct {
+	Token   string   `json:"token"`
+	Details TokenDTO `json:"details"`
+}
+
+type RevokeTokenDTO struct {
+	TokenID int64 `json:"token_id"`
+}
+
+// AuditRecordDTO - запись журнала аудита; payload и result - JSON параметров и итога операции.
+// actor_id - заявленный автор, token_id, token_name и role - токен, которым выполнен запрос.
+type AuditRecordDTO struct {
+	AuditID   int64           `json:"audit_id"`
+	ActorID   string          `json:"actor_id,omitempty"`
+	TokenID   int64           `json:"token_id,omitempty"`
+	TokenName string          `json:"token_name,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Operation string          `json:"operation"`
+	EntityID  string          `json:"entity_id"`
+	Payload   json.RawMessage `json:"payload"`
+	Result    json.RawMessage `json:"result"`
+	CreatedAt string          `json:"created_at"`
+}
+
+type GetAuditLogResponseDTO struct {
+	Records []AuditRecordDTO `json:"records"`
+	// курсор следующей страницы, отсутствует на последней
+	NextCursor string `json:"next_cursor,omitempty"`
 }
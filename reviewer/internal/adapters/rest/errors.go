@@ -26,6 +26,15 @@ func writeError(w http.ResponseWriter, code int, errorCode, message string) {
 	})
 }
 
+// errorStatus возвращает статус ответа на ошибку с кодом errorCode. Отказ в доступе даёт 403
+// во всех обработчиках, остальные коды - статус statusCode, выбранный обработчиком.
+func errorStatus(errorCode string, statusCode int) int {
+	if errorCode == "FORBIDDEN" {
+		return http.StatusForbidden
+	}
+	return statusCode
+}
+
 func writeJSON(w http.ResponseWriter, code int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"pr-reviewer/internal/core"
+)
+
+// GitHub ограничивает payload 25 МБ, для GitLab этого тоже достаточно.
+const maxWebhookBodySize = 25 << 20
+
+type gitHubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title  string `json:"title"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		MergedBy struct {
+			Login string `json:"login"`
+		} `json:"merged_by"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// prID - идентификатор PR в сервисе: "owner/repo#number".
+func (e gitHubPullRequestEvent) prID() string {
+	return fmt.Sprintf("%s#%d", e.Repository.FullName, e.Number)
+}
+
+// POST /integrations/github/webhook.
+func GitHubWebhookHandler(log *slog.Logger, service *core.Service, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			log.Error("failed to read webhook body", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		if !validGitHubSignature(secret, r.Header.Get("X-Hub-Signature-256"), body) {
+			log.Error("invalid github webhook signature", "delivery", r.Header.Get("X-GitHub-Delivery"))
+			writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "signature mismatch")
+			return
+		}
+
+		switch eventName := r.Header.Get("X-GitHub-Event"); eventName {
+		case "ping":
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "pong"})
+			return
+		case "pull_request":
+		default:
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "unsupported event " + eventName})
+			return
+		}
+
+		var event gitHubPullRequestEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			log.Error("failed to decode github event", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		if event.Repository.FullName == "" || event.Number == 0 {
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "repository.full_name and number are required")
+			return
+		}
+
+		var pr *core.PullRequest
+		switch {
+		case event.Action == "opened":
+			pr, err = openExternalPR(r, service, core.ProviderGitHub, event.prID(), event.PullRequest.Title, event.PullRequest.User.Login)
+		case event.Action == "closed" && event.PullRequest.Merged:
+			pr, err = mergeExternalPR(r, service, core.ProviderGitHub, event.prID(), event.PullRequest.MergedBy.Login)
+		case event.Action == "closed":
+			pr, err = service.ClosePR(r.Context(), event.prID())
+		case event.Action == "reopened":
+			pr, _, err = service.ReopenPR(r.Context(), event.prID())
+		default:
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "unsupported action " + event.Action})
+			return
+		}
+
+		if errors.Is(err, core.ErrPRExists) {
+			// повторная доставка того же события
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "pull request already exists"})
+			return
+		}
+		if err != nil {
+			writeIntegrationError(log, w, "failed to process github event", err)
+			return
+		}
+
+		response, err := integrationResponse(r, service, core.ProviderGitHub, pr)
+		if err != nil {
+			log.Error("failed to build github response", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// POST /integrations/accounts/link.
+func LinkExternalAccountHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ExternalAccountDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		account := core.ExternalAccount{Provider: req.Provider, Login: req.Login, UserID: req.UserID}
+		if err := service.LinkExternalAccount(r.Context(), account); err != nil {
+			writeIntegrationError(log, w, "failed to link external account", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"account": req})
+	}
+}
+
+func openExternalPR(r *http.Request, service *core.Service, provider, prID, title, login string) (*core.PullRequest, error) {
+	authorID, err := service.ResolveExternalUser(r.Context(), provider, login)
+	if err != nil {
+		return nil, err
+	}
+	return service.CreatePR(r.Context(), prID, title, authorID)
+}
+
+// mergeExternalPR отражает merge, который уже произошёл во внешней системе, поэтому
+// политика команды не блокирует его, а нарушение записывается как обход от её имени.
+func mergeExternalPR(r *http.Request, service *core.Service, provider, prID, login string) (*core.PullRequest, error) {
+	ctx := core.WithActor(r.Context(), provider+":"+login)
+	return service.MergePRWithOptions(ctx, prID, core.MergeOptions{
+		Force:  true,
+		Reason: "merged in " + provider,
+	})
+}
+
+func writeIntegrationError(log *slog.Logger, w http.ResponseWriter, msg string, err error) {
+	errorCode, ok := mapErrorToCode(err)
+	if !ok {
+		log.Error(msg, "error", err)
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	statusCode := http.StatusConflict
+	switch errorCode {
+	case "NOT_FOUND":
+		statusCode = http.StatusNotFound
+	case "INVALID_ACCOUNT":
+		statusCode = http.StatusBadRequest
+	case "UNKNOWN_ACCOUNT":
+		statusCode = http.StatusUnprocessableEntity
+	case "INTEGRATIONS_DISABLED":
+		statusCode = http.StatusServiceUnavailable
+	}
+	log.Error(msg, "error", err, "code", errorCode)
+	writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+}
+
+// validGitHubSignature проверяет заголовок X-Hub-Signature-256 ("sha256=<hex>").
+func validGitHubSignature(secret, header string, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
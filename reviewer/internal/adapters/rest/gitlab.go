@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"pr-reviewer/internal/core"
+)
+
+type gitLabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+// prID - идентификатор MR в сервисе: "group/project!iid".
+func (e gitLabMergeRequestEvent) prID() string {
+	return fmt.Sprintf("%s!%d", e.Project.PathWithNamespace, e.ObjectAttributes.IID)
+}
+
+// POST /integrations/gitlab/webhook.
+func GitLabWebhookHandler(log *slog.Logger, service *core.Service, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !validGitLabToken(token, r.Header.Get("X-Gitlab-Token")) {
+			log.Error("invalid gitlab webhook token")
+			writeError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "token mismatch")
+			return
+		}
+
+		if eventName := r.Header.Get("X-Gitlab-Event"); eventName != "Merge Request Hook" {
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "unsupported event " + eventName})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			log.Error("failed to read webhook body", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		var event gitLabMergeRequestEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			log.Error("failed to decode gitlab event", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		if event.Project.PathWithNamespace == "" || event.ObjectAttributes.IID == 0 {
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "project.path_with_namespace and object_attributes.iid are required")
+			return
+		}
+
+		var pr *core.PullRequest
+		switch action := event.ObjectAttributes.Action; action {
+		case "open":
+			// MR открывает его автор, поэтому автор берётся из user
+			pr, err = openExternalPR(r, service, core.ProviderGitLab, event.prID(), event.ObjectAttributes.Title, event.User.Username)
+		case "merge":
+			pr, err = mergeExternalPR(r, service, core.ProviderGitLab, event.prID(), event.User.Username)
+		case "close":
+			pr, err = service.ClosePR(r.Context(), event.prID())
+		case "reopen":
+			pr, _, err = service.ReopenPR(r.Context(), event.prID())
+		default:
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "unsupported action " + action})
+			return
+		}
+
+		if errors.Is(err, core.ErrPRExists) {
+			// повторная доставка того же события
+			writeJSON(w, http.StatusOK, IntegrationResponseDTO{Status: "ignored", Reason: "pull request already exists"})
+			return
+		}
+		if err != nil {
+			writeIntegrationError(log, w, "failed to process gitlab event", err)
+			return
+		}
+
+		response, err := integrationResponse(r, service, core.ProviderGitLab, pr)
+		if err != nil {
+			log.Error("failed to build gitlab response", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// integrationResponse дополняет PR логинами ревьюверов во внешней системе,
+// чтобы бот мог назначить их на MR.
+func integrationResponse(r *http.Request, service *core.Service, provider string, pr *core.PullRequest) (IntegrationResponseDTO, error) {
+	prDTO, err := prToDTO(pr)
+	if err != nil {
+		return IntegrationResponseDTO{}, err
+	}
+
+	logins, err := service.ExternalLogins(r.Context(), provider, pr.ReviewersIDs)
+	if err != nil {
+		return IntegrationResponseDTO{}, err
+	}
+
+	reviewers := make([]ExternalReviewerDTO, len(pr.ReviewersIDs))
+	for i, id := range pr.ReviewersIDs {
+		reviewers[i] = ExternalReviewerDTO{UserID: id}
+		if login, ok := logins[id]; ok {
+			reviewers[i].Login = &login
+		}
+	}
+
+	return IntegrationResponseDTO{Status: "processed", PR: &prDTO, Reviewers: reviewers}, nil
+}
+
+func validGitLabToken(expected, got string) bool {
+	if expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(got)) == 1
+}
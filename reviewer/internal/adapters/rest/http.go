@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"pr-reviewer/internal/core"
 )
@@ -25,11 +26,11 @@ func CreateTeamHandler(log *slog.Logger, service *core.Service) http.HandlerFunc
 			return
 		}
 
-		err = service.CreateTeam(r.Context(), team.Name, team.Members)
+		err = service.CreateTeamWithSettings(r.Context(), team.Name, team.Members, team.Settings)
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				log.Error("failed to create team", "error", err, "code", errorCode)
-				writeError(w, http.StatusBadRequest, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, http.StatusBadRequest), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to create team", "error", err)
@@ -71,7 +72,7 @@ func GetTeamHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				log.Error("failed to get team", "error", err, "code", errorCode)
-				writeError(w, http.StatusNotFound, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, http.StatusNotFound), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to get team", "error", err)
@@ -90,6 +91,139 @@ func GetTeamHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	}
 }
 
+// POST /team/updateSettings.
+func UpdateTeamSettingsHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateTeamSettingsDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		patch, err := teamSettingsPatchFromDTO(req)
+		if err != nil {
+			log.Error("failed to validate team settings DTO", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		team, err := service.UpdateTeamSettings(r.Context(), req.TeamName, patch)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "INVALID_SETTINGS" {
+					statusCode = http.StatusBadRequest
+				}
+				log.Error("failed to update team settings", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to update team settings", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		teamDTO, err := teamToDTO(team)
+		if err != nil {
+			log.Error("failed to convert team to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"team": teamDTO})
+	}
+}
+
+// POST /team/setCodeOwners.
+func SetCodeOwnersHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetCodeOwnersDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		rules, err := ownershipRulesFromDTO(req)
+		if err != nil {
+			log.Error("failed to validate code owners DTO", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		team, err := service.SetOwnershipRules(r.Context(), req.TeamName, rules)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "INVALID_CODE_OWNERS" {
+					statusCode = http.StatusBadRequest
+				}
+				log.Error("failed to set code owners", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to set code owners", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		teamDTO, err := teamToDTO(team)
+		if err != nil {
+			log.Error("failed to convert team to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"team": teamDTO})
+	}
+}
+
+// POST /team/deactivateUsers.
+func DeactivateTeamUsersHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeactivateTeamUsersDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		if req.TeamName == "" || len(req.UserIDs) == 0 {
+			log.Error("team_name and user_ids are required")
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name and user_ids are required")
+			return
+		}
+
+		users, reassignments, err := service.DeactivateTeamUsers(r.Context(), req.TeamName, req.UserIDs)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to deactivate team users", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, http.StatusNotFound), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to deactivate team users", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		usersDTO, err := usersToDTOs(users)
+		if err != nil {
+			log.Error("failed to convert users to DTOs", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		response := DeactivateTeamUsersResponseDTO{
+			TeamName:      req.TeamName,
+			Users:         usersDTO,
+			Reassignments: reassignmentsToDTOs(reassignments),
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
 // POST /users/setIsActive.
 func SetUserActiveHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -100,11 +234,13 @@ func SetUserActiveHandler(log *slog.Logger, service *core.Service) http.HandlerF
 			return
 		}
 
-		user, err := service.SetUserActive(r.Context(), req.UserID, req.IsActive)
+		// reassign=true заменяет деактивированного пользователя в открытых PR независимо от настройки команды
+		opts := core.SetUserActiveOptions{Reassign: r.URL.Query().Get("reassign") == "true"}
+		user, reassignments, err := service.SetUserActiveWithOptions(r.Context(), req.UserID, req.IsActive, opts)
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				log.Error("failed to set user active", "error", err, "code", errorCode)
-				writeError(w, http.StatusNotFound, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, http.StatusNotFound), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to set user active", "error", err)
@@ -118,6 +254,53 @@ func SetUserActiveHandler(log *slog.Logger, service *core.Service) http.HandlerF
 			return
 		}
 
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user":          userDTO,
+			"reassignments": reassignmentsToDTOs(reassignments),
+		})
+	}
+}
+
+// POST /users/setUnavailability.
+func SetUnavailabilityHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetUnavailabilityDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		windows, err := unavailabilityFromDTO(req)
+		if err != nil {
+			log.Error("failed to validate unavailability DTO", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		user, err := service.SetUserUnavailability(r.Context(), req.UserID, windows)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "INVALID_UNAVAILABILITY" {
+					statusCode = http.StatusBadRequest
+				}
+				log.Error("failed to set user unavailability", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to set user unavailability", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		userDTO, err := userToDTO(user)
+		if err != nil {
+			log.Error("failed to convert user to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
 		writeJSON(w, http.StatusOK, map[string]interface{}{"user": userDTO})
 	}
 }
@@ -132,7 +315,8 @@ func CreatePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 			return
 		}
 
-		pr, err := service.CreatePR(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
+		opts := core.CreatePROptions{ChangedFiles: req.ChangedFiles}
+		pr, err := service.CreatePRWithOptions(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID, opts)
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				statusCode := http.StatusNotFound
@@ -140,7 +324,7 @@ func CreatePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 					statusCode = http.StatusConflict
 				}
 				log.Error("failed to create PR", "error", err, "code", errorCode)
-				writeError(w, statusCode, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to create PR", "error", err)
@@ -148,6 +332,15 @@ func CreatePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 			return
 		}
 
+		if missing := pr.MissingReviewers(); missing > 0 {
+			log.Warn("PR has fewer reviewers than required",
+				"pull_request_id", pr.ID,
+				"assigned", len(pr.ReviewersIDs),
+				"required", pr.RequiredReviewers,
+				"missing", missing,
+			)
+		}
+
 		prDTO, err := prToDTO(pr)
 		if err != nil {
 			log.Error("failed to convert PR to DTO", "error", err)
@@ -169,11 +362,19 @@ func MergePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 			return
 		}
 
-		pr, err := service.MergePR(r.Context(), req.PullRequestID)
+		opts := core.MergeOptions{Force: req.Force, ActorID: req.ActorID, Reason: req.Reason}
+		pr, err := service.MergePRWithOptions(r.Context(), req.PullRequestID, opts)
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				switch errorCode {
+				case "PR_CLOSED", "MERGE_BLOCKED":
+					statusCode = http.StatusConflict
+				case "INVALID_OVERRIDE":
+					statusCode = http.StatusBadRequest
+				}
 				log.Error("failed to merge PR", "error", err, "code", errorCode)
-				writeError(w, http.StatusNotFound, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to merge PR", "error", err)
@@ -192,6 +393,123 @@ func MergePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	}
 }
 
+// POST /pullRequest/review.
+func SubmitReviewHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SubmitReviewDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		pr, err := service.SubmitReview(r.Context(), req.PullRequestID, req.ReviewerID, core.ReviewState(req.State))
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				switch errorCode {
+				case "INVALID_REVIEW_STATE":
+					statusCode = http.StatusBadRequest
+				case "PR_MERGED", "PR_CLOSED", "NOT_ASSIGNED":
+					statusCode = http.StatusConflict
+				}
+				log.Error("failed to submit review", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to submit review", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prDTO, err := prToDTO(pr)
+		if err != nil {
+			log.Error("failed to convert PR to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"pr": prDTO})
+	}
+}
+
+// POST /pullRequest/close.
+func ClosePRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ClosePRDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		pr, err := service.ClosePR(r.Context(), req.PullRequestID)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "PR_MERGED" {
+					statusCode = http.StatusConflict
+				}
+				log.Error("failed to close PR", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to close PR", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prDTO, err := prToDTO(pr)
+		if err != nil {
+			log.Error("failed to convert PR to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"pr": prDTO})
+	}
+}
+
+// POST /pullRequest/reopen.
+func ReopenPRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReopenPRDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		pr, reassignments, err := service.ReopenPR(r.Context(), req.PullRequestID)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "PR_MERGED" {
+					statusCode = http.StatusConflict
+				}
+				log.Error("failed to reopen PR", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to reopen PR", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prDTO, err := prToDTO(pr)
+		if err != nil {
+			log.Error("failed to convert PR to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, ReopenPRResponseDTO{
+			PR:            prDTO,
+			Reassignments: reassignmentsToDTOs(reassignments),
+		})
+	}
+}
+
 // POST /pullRequest/reassign.
 func ReassignReviewerHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -206,11 +524,11 @@ func ReassignReviewerHandler(log *slog.Logger, service *core.Service) http.Handl
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				statusCode := http.StatusNotFound
-				if errorCode == "PR_MERGED" || errorCode == "NOT_ASSIGNED" || errorCode == "NO_CANDIDATE" {
+				if errorCode == "PR_MERGED" || errorCode == "PR_CLOSED" || errorCode == "NOT_ASSIGNED" || errorCode == "NO_CANDIDATE" {
 					statusCode = http.StatusConflict
 				}
 				log.Error("failed to reassign reviewer", "error", err, "code", errorCode)
-				writeError(w, statusCode, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to reassign reviewer", "error", err)
@@ -234,8 +552,8 @@ func ReassignReviewerHandler(log *slog.Logger, service *core.Service) http.Handl
 	}
 }
 
-// GetUserReviews получает все PR пользователя
-// GET /users/getReview?user_id=...
+// GetUserReviews получает все PR пользователя, pending=true оставляет только ожидающие его ревью
+// GET /users/getReview?user_id=...&pending=true
 func GetUserReviewsHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.URL.Query().Get("user_id")
@@ -245,11 +563,27 @@ func GetUserReviewsHandler(log *slog.Logger, service *core.Service) http.Handler
 			return
 		}
 
-		prs, err := service.GetUserReviews(r.Context(), userID)
+		pendingOnly := false
+		if pending := r.URL.Query().Get("pending"); pending != "" {
+			var err error
+			pendingOnly, err = strconv.ParseBool(pending)
+			if err != nil {
+				log.Error("invalid pending flag", "error", err)
+				writeError(w, http.StatusBadRequest, "BAD_REQUEST", "pending must be a boolean")
+				return
+			}
+		}
+
+		getReviews := service.GetUserReviews
+		if pendingOnly {
+			getReviews = service.GetPendingReviews
+		}
+
+		prs, err := getReviews(r.Context(), userID)
 		if err != nil {
 			if errorCode, ok := mapErrorToCode(err); ok {
 				log.Error("failed to get user reviews", "error", err, "code", errorCode)
-				writeError(w, http.StatusNotFound, errorCode, err.Error())
+				writeError(w, errorStatus(errorCode, http.StatusNotFound), errorCode, err.Error())
 				return
 			}
 			log.Error("failed to get user reviews", "error", err)
@@ -263,6 +597,9 @@ func GetUserReviewsHandler(log *slog.Logger, service *core.Service) http.Handler
 			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 			return
 		}
+		for i, pr := range prs {
+			prsDTO[i].ReviewState = string(pr.Review(userID).State)
+		}
 
 		response := GetUserReviewsResponseDTO{
 			UserID:       userID,
@@ -273,11 +610,159 @@ func GetUserReviewsHandler(log *slog.Logger, service *core.Service) http.Handler
 	}
 }
 
+// GET /pullRequest/get.
+func GetPRHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prID := r.URL.Query().Get("pull_request_id")
+		if prID == "" {
+			log.Error("pull_request_id is required")
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+			return
+		}
+
+		pr, err := service.GetPR(r.Context(), prID)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to get PR", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, http.StatusNotFound), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to get PR", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prDTO, err := prToDTO(pr)
+		if err != nil {
+			log.Error("failed to convert PR to DTO", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"pr": prDTO})
+	}
+}
+
+// GET /pullRequest/list.
+func ListPRsHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := prFilterFromQuery(r.URL.Query())
+		if err != nil {
+			log.Error("failed to parse PR filter", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		page, err := service.ListPRs(r.Context(), filter)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "INVALID_FILTER" {
+					statusCode = http.StatusBadRequest
+				}
+				log.Error("failed to list PRs", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to list PRs", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prsDTO, err := prsToDTOs(page.PullRequests)
+		if err != nil {
+			log.Error("failed to convert PRs to DTOs", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, ListPRsResponseDTO{
+			PullRequests: prsDTO,
+			NextCursor:   encodePRCursor(page.Next),
+		})
+	}
+}
+
+// GET /pullRequest/overdue.
+func GetOverduePRsHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		overdue, err := service.GetOverduePRs(r.Context())
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to get overdue PRs", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, http.StatusInternalServerError), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to get overdue PRs", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		prsDTO, err := overduePRsToDTOs(overdue)
+		if err != nil {
+			log.Error("failed to convert overdue PRs to DTOs", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, GetOverduePRsResponseDTO{PullRequests: prsDTO})
+	}
+}
+
+// GET /pullRequest/history.
+func GetPRHistoryHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prID := r.URL.Query().Get("pull_request_id")
+		if prID == "" {
+			log.Error("pull_request_id is required")
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+			return
+		}
+
+		history, err := service.GetPRHistory(r.Context(), prID)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "HISTORY_DISABLED" {
+					statusCode = http.StatusServiceUnavailable
+				}
+				log.Error("failed to get PR history", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to get PR history", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, GetPRHistoryResponseDTO{
+			PullRequestID: prID,
+			History:       assignmentsToDTOs(history),
+		})
+	}
+}
+
 // GET /statistics.
 func GetStatisticsHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		stats, err := service.GetStatistics(r.Context())
+		filter, err := statisticsFilterFromQuery(r.URL.Query())
+		if err != nil {
+			log.Error("failed to parse statistics filter", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		stats, err := service.GetStatistics(r.Context(), filter)
 		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				if errorCode == "INVALID_FILTER" {
+					statusCode = http.StatusBadRequest
+				}
+				log.Error("failed to get statistics", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
 			log.Error("failed to get statistics", "error", err)
 			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 			return
@@ -288,3 +773,211 @@ func GetStatisticsHandler(log *slog.Logger, service *core.Service) http.HandlerF
 		writeJSON(w, http.StatusOK, response)
 	}
 }
+
+// GET /audit.
+func GetAuditLogHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := auditFilterFromQuery(r.URL.Query())
+		if err != nil {
+			log.Error("failed to parse audit filter", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		page, err := service.GetAuditLog(r.Context(), filter)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				statusCode := http.StatusNotFound
+				switch errorCode {
+				case "INVALID_FILTER":
+					statusCode = http.StatusBadRequest
+				case "AUDIT_DISABLED":
+					statusCode = http.StatusServiceUnavailable
+				}
+				log.Error("failed to get audit log", "error", err, "code", errorCode)
+				writeError(w, errorStatus(errorCode, statusCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to get audit log", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, GetAuditLogResponseDTO{
+			Records:    auditRecordsToDTOs(page.Records),
+			NextCursor: encodeAuditCursor(page.NextBeforeID),
+		})
+	}
+}
+
+// POST /webhooks/add.
+func CreateWebhookHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateWebhookDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		subscription := webhookFromDTO(req)
+		if err := service.RegisterWebhook(r.Context(), subscription); err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to register webhook", "error", err, "code", errorCode)
+				writeError(w, webhookErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to register webhook", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"webhook": webhookToDTO(*subscription)})
+	}
+}
+
+// GET /webhooks/list.
+func ListWebhooksHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscriptions, err := service.ListWebhooks(r.Context())
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to list webhooks", "error", err, "code", errorCode)
+				writeError(w, webhookErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to list webhooks", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		webhooks := make([]WebhookDTO, len(subscriptions))
+		for i, subscription := range subscriptions {
+			webhooks[i] = webhookToDTO(subscription)
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": webhooks})
+	}
+}
+
+// POST /webhooks/delete.
+func DeleteWebhookHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeleteWebhookDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		if err := service.DeleteWebhook(r.Context(), req.WebhookID); err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to delete webhook", "error", err, "code", errorCode)
+				writeError(w, webhookErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to delete webhook", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func webhookErrorStatus(errorCode string) int {
+	switch errorCode {
+	case "INVALID_WEBHOOK":
+		return http.StatusBadRequest
+	case "WEBHOOKS_DISABLED":
+		return http.StatusServiceUnavailable
+	default:
+		return errorStatus(errorCode, http.StatusNotFound)
+	}
+}
+
+// POST /auth/tokens/add.
+func CreateTokenHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateTokenDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		apiToken, token, err := service.CreateToken(r.Context(), req.Name, core.Role(req.Role), req.UserID)
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to create token", "error", err, "code", errorCode)
+				writeError(w, tokenErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to create token", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, CreateTokenResponseDTO{Token: token, Details: tokenToDTO(*apiToken)})
+	}
+}
+
+// GET /auth/tokens/list.
+func ListTokensHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiTokens, err := service.ListTokens(r.Context())
+		if err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to list tokens", "error", err, "code", errorCode)
+				writeError(w, tokenErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to list tokens", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		tokens := make([]TokenDTO, len(apiTokens))
+		for i, apiToken := range apiTokens {
+			tokens[i] = tokenToDTO(apiToken)
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+	}
+}
+
+// POST /auth/tokens/revoke.
+func RevokeTokenHandler(log *slog.Logger, service *core.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RevokeTokenDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request", "error", err)
+			writeError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+
+		if err := service.RevokeToken(r.Context(), req.TokenID); err != nil {
+			if errorCode, ok := mapErrorToCode(err); ok {
+				log.Error("failed to revoke token", "error", err, "code", errorCode)
+				writeError(w, tokenErrorStatus(errorCode), errorCode, err.Error())
+				return
+			}
+			log.Error("failed to revoke token", "error", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func tokenErrorStatus(errorCode string) int {
+	switch errorCode {
+	case "INVALID_TOKEN":
+		return http.StatusBadRequest
+	case "AUTH_DISABLED":
+		return http.StatusServiceUnavailable
+	default:
+		return errorStatus(errorCode, http.StatusNotFound)
+	}
+}
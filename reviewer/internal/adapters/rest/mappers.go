@@ -1,8 +1,13 @@
 package rest
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"pr-reviewer/internal/core"
 )
@@ -13,8 +18,11 @@ var (
 	ErrInvalidUser      = errors.New("invalid user: user is nil or required fields are empty")
 	ErrInvalidUserDTO   = errors.New("invalid user DTO: user_id and username are required")
 	ErrInvalidPR        = errors.New("invalid pull request: PR is nil or required fields are empty")
-	ErrInvalidStatus    = errors.New("invalid status: must be OPEN or MERGED")
+	ErrInvalidStatus    = errors.New("invalid status: must be OPEN, MERGED or CLOSED")
 	ErrInvalidMemberDTO = errors.New("invalid team member: user_id and username are required")
+	ErrInvalidWindowDTO = errors.New("invalid unavailability window: from and to must be RFC 3339 timestamps")
+	ErrInvalidSLADTO    = errors.New("invalid review_sla: must be a duration like 24h")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
 )
 
 func teamToDTO(team *core.Team) (TeamDTO, error) {
@@ -34,11 +42,56 @@ func teamToDTO(team *core.Team) (TeamDTO, error) {
 		}
 	}
 	return TeamDTO{
-		TeamName: team.Name,
-		Members:  members,
+		TeamName:          team.Name,
+		Members:           members,
+		ReviewerStrategy:  string(team.Settings.ReviewerStrategy),
+		RequiredReviewers: team.Settings.RequiredReviewers,
+		RequiredApprovals: team.Settings.RequiredApprovals,
+		FallbackTeams:     team.Settings.FallbackTeams,
+		AutoReassign:      team.Settings.AutoReassign,
+		ReviewSLA:         reviewSLAToDTO(team.Settings.ReviewSLA),
+		CodeOwners:        ownershipRulesToDTOs(team.OwnershipRules),
 	}, nil
 }
 
+func reviewSLAToDTO(sla time.Duration) string {
+	if sla == 0 {
+		return ""
+	}
+	return sla.String()
+}
+
+func reviewSLAFromDTO(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	sla, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSLADTO, value)
+	}
+	return sla, nil
+}
+
+func ownershipRulesToDTOs(rules []core.OwnershipRule) []OwnershipRuleDTO {
+	result := make([]OwnershipRuleDTO, len(rules))
+	for i, rule := range rules {
+		result[i] = OwnershipRuleDTO{Pattern: rule.Pattern, Users: rule.Users, Teams: rule.Teams}
+	}
+	return result
+}
+
+func ownershipRulesFromDTO(dto SetCodeOwnersDTO) ([]core.OwnershipRule, error) {
+	if dto.TeamName == "" {
+		return nil, ErrInvalidTeamDTO
+	}
+
+	rules := make([]core.OwnershipRule, len(dto.Rules))
+	for i, rule := range dto.Rules {
+		rules[i] = core.OwnershipRule{Pattern: rule.Pattern, Users: rule.Users, Teams: rule.Teams}
+	}
+	return rules, nil
+}
+
 func teamFromDTO(dto TeamDTO) (*core.Team, error) {
 	if dto.TeamName == "" {
 		return nil, ErrInvalidTeamDTO
@@ -56,12 +109,49 @@ func teamFromDTO(dto TeamDTO) (*core.Team, error) {
 			IsActive: member.IsActive,
 		}
 	}
+	reviewSLA, err := reviewSLAFromDTO(dto.ReviewSLA)
+	if err != nil {
+		return nil, err
+	}
+
 	return &core.Team{
 		Name:    dto.TeamName,
 		Members: members,
+		Settings: core.TeamSettings{
+			ReviewerStrategy:  core.SelectionStrategy(dto.ReviewerStrategy),
+			RequiredReviewers: dto.RequiredReviewers,
+			RequiredApprovals: dto.RequiredApprovals,
+			FallbackTeams:     dto.FallbackTeams,
+			AutoReassign:      dto.AutoReassign,
+			ReviewSLA:         reviewSLA,
+		},
 	}, nil
 }
 
+func teamSettingsPatchFromDTO(dto UpdateTeamSettingsDTO) (core.TeamSettingsPatch, error) {
+	if dto.TeamName == "" {
+		return core.TeamSettingsPatch{}, ErrInvalidTeamDTO
+	}
+
+	var patch core.TeamSettingsPatch
+	if dto.ReviewerStrategy != nil {
+		strategy := core.SelectionStrategy(*dto.ReviewerStrategy)
+		patch.ReviewerStrategy = &strategy
+	}
+	patch.RequiredReviewers = dto.RequiredReviewers
+	patch.RequiredApprovals = dto.RequiredApprovals
+	patch.FallbackTeams = dto.FallbackTeams
+	patch.AutoReassign = dto.AutoReassign
+	if dto.ReviewSLA != nil {
+		reviewSLA, err := reviewSLAFromDTO(*dto.ReviewSLA)
+		if err != nil {
+			return core.TeamSettingsPatch{}, err
+		}
+		patch.ReviewSLA = &reviewSLA
+	}
+	return patch, nil
+}
+
 func userToDTO(user *core.User) (UserDTO, error) {
 	if user == nil {
 		return UserDTO{}, ErrInvalidUser
@@ -70,12 +160,75 @@ func userToDTO(user *core.User) (UserDTO, error) {
 		return UserDTO{}, fmt.Errorf("%w: user_id and username are required", ErrInvalidUser)
 	}
 
-	return UserDTO{
+	dto := UserDTO{
 		UserID:   user.ID,
 		Username: user.Username,
 		TeamName: user.TeamName,
 		IsActive: user.IsActive,
-	}, nil
+	}
+	for _, window := range user.Unavailability {
+		dto.Unavailability = append(dto.Unavailability, UnavailabilityDTO{
+			From: window.From.UTC().Format(time.RFC3339),
+			To:   window.To.UTC().Format(time.RFC3339),
+		})
+	}
+	return dto, nil
+}
+
+func unavailabilityFromDTO(dto SetUnavailabilityDTO) ([]core.Unavailability, error) {
+	if dto.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidUserDTO)
+	}
+
+	windows := make([]core.Unavailability, len(dto.Windows))
+	for i, window := range dto.Windows {
+		from, err := time.Parse(time.RFC3339, window.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: window at index %d", ErrInvalidWindowDTO, i)
+		}
+		to, err := time.Parse(time.RFC3339, window.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: window at index %d", ErrInvalidWindowDTO, i)
+		}
+		windows[i] = core.Unavailability{From: from, To: to}
+	}
+	return windows, nil
+}
+
+func usersToDTOs(users []*core.User) ([]UserDTO, error) {
+	result := make([]UserDTO, len(users))
+	for i, user := range users {
+		dto, err := userToDTO(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert user at index %d: %w", i, err)
+		}
+		result[i] = dto
+	}
+	return result, nil
+}
+
+// reassignmentsToDTOs переводит замены в DTO, снятый без замены ревьювер отдаётся как null.
+func reassignmentsToDTOs(reassignments []core.Reassignment) []ReassignmentDTO {
+	result := make([]ReassignmentDTO, len(reassignments))
+	for i, reassignment := range reassignments {
+		result[i] = ReassignmentDTO{
+			PullRequestID: reassignment.PullRequestID,
+			OldReviewerID: reassignment.OldReviewerID,
+			FallbackTeam:  reassignment.FallbackTeam,
+		}
+		if reassignment.NewReviewerID != "" {
+			newReviewerID := reassignment.NewReviewerID
+			result[i].NewReviewerID = &newReviewerID
+		}
+		if reassignment.Err != nil {
+			code, ok := mapErrorToCode(reassignment.Err)
+			if !ok {
+				code = "INTERNAL_ERROR"
+			}
+			result[i].Error = &ErrorDetail{Code: code, Message: reassignment.Err.Error()}
+		}
+	}
+	return result
 }
 
 func prToDTO(pr *core.PullRequest) (PullRequestDTO, error) {
@@ -87,17 +240,181 @@ func prToDTO(pr *core.PullRequest) (PullRequestDTO, error) {
 	}
 
 	status := string(pr.Status)
-	if status != string(core.PullRequestStatusOpen) && status != string(core.PullRequestStatusMerged) {
+	if !pr.Status.IsValid() {
 		return PullRequestDTO{}, ErrInvalidStatus
 	}
 
-	return PullRequestDTO{
+	dto := PullRequestDTO{
 		PullRequestID:     pr.ID,
 		PullRequestName:   pr.Name,
 		AuthorID:          pr.AuthorID,
 		Status:            status,
 		AssignedReviewers: pr.ReviewersIDs,
-	}, nil
+		RequiredReviewers: pr.RequiredReviewers,
+		MissingReviewers:  pr.MissingReviewers(),
+		Reviews:           reviewsToDTOs(pr),
+		MergeOverride:     mergeOverrideToDTO(pr.MergeOverride),
+		MergedAt:          timeToDTO(pr.MergedAt),
+	}
+	if !pr.CreatedAt.IsZero() {
+		dto.CreatedAt = timeToDTO(&pr.CreatedAt)
+	}
+	return dto, nil
+}
+
+func prsToDTOs(prs []*core.PullRequest) ([]PullRequestDTO, error) {
+	result := make([]PullRequestDTO, len(prs))
+	for i, pr := range prs {
+		dto, err := prToDTO(pr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert PR at index %d: %w", i, err)
+		}
+		result[i] = dto
+	}
+	return result, nil
+}
+
+// prFilterFromQuery разбирает параметры GET /pullRequest/list. Время задаётся в RFC 3339.
+func prFilterFromQuery(query url.Values) (core.PRFilter, error) {
+	filter := core.PRFilter{
+		Status:     core.PullRequestStatus(query.Get("status")),
+		AuthorID:   query.Get("author_id"),
+		ReviewerID: query.Get("reviewer_id"),
+		TeamName:   query.Get("team_name"),
+	}
+
+	times := map[string]**time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+		"merged_from":  &filter.MergedFrom,
+		"merged_to":    &filter.MergedTo,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return core.PRFilter{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", ErrInvalidQuery, name)
+		}
+		*target = &parsed
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return core.PRFilter{}, fmt.Errorf("%w: limit must be a positive integer", ErrInvalidQuery)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodePRCursor(value)
+		if err != nil {
+			return core.PRFilter{}, err
+		}
+		filter.After = cursor
+	}
+	return filter, nil
+}
+
+// encodePRCursor упаковывает курсор в непрозрачную строку: время создания и ID PR.
+func encodePRCursor(cursor *core.PRCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	raw := cursor.CreatedAt.Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePRCursor(value string) (*core.PRCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return &core.PRCursor{CreatedAt: parsed, ID: id}, nil
+}
+
+func mergeOverrideToDTO(override *core.MergeOverride) *MergeOverrideDTO {
+	if override == nil {
+		return nil
+	}
+	return &MergeOverrideDTO{ActorID: override.ActorID, Reason: override.Reason}
+}
+
+func reviewsToDTOs(pr *core.PullRequest) []ReviewDTO {
+	result := make([]ReviewDTO, len(pr.ReviewersIDs))
+	for i, reviewerID := range pr.ReviewersIDs {
+		review := pr.Review(reviewerID)
+		result[i] = ReviewDTO{
+			ReviewerID:   reviewerID,
+			State:        string(review.State),
+			AssignedAt:   review.AssignedAt.UTC().Format(time.RFC3339),
+			UpdatedAt:    review.UpdatedAt.UTC().Format(time.RFC3339),
+			FallbackTeam: review.FallbackTeam,
+			OwnerPattern: review.OwnerPattern,
+			EscalatedAt:  timeToDTO(review.EscalatedAt),
+		}
+	}
+	return result
+}
+
+func timeToDTO(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := value.UTC().Format(time.RFC3339)
+	return &formatted
+}
+
+func overduePRsToDTOs(overdue []core.OverduePR) ([]OverduePRDTO, error) {
+	result := make([]OverduePRDTO, len(overdue))
+	for i, item := range overdue {
+		short, err := prToShortDTO(item.PullRequest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert PR at index %d: %w", i, err)
+		}
+
+		reviews := make([]OverdueReviewDTO, len(item.Reviews))
+		for j, review := range item.Reviews {
+			reviews[j] = OverdueReviewDTO{
+				ReviewerID:  review.ReviewerID,
+				AssignedAt:  review.AssignedAt.UTC().Format(time.RFC3339),
+				DueAt:       review.AssignedAt.Add(item.SLA).UTC().Format(time.RFC3339),
+				EscalatedAt: timeToDTO(review.EscalatedAt),
+			}
+		}
+		result[i] = OverduePRDTO{
+			PullRequestShortDTO: short,
+			ReviewSLA:           item.SLA.String(),
+			OverdueReviewers:    reviews,
+		}
+	}
+	return result, nil
+}
+
+func assignmentsToDTOs(assignments []core.Assignment) []AssignmentDTO {
+	result := make([]AssignmentDTO, len(assignments))
+	for i, assignment := range assignments {
+		result[i] = AssignmentDTO{
+			ReviewerID:         assignment.ReviewerID,
+			AssignedAt:         assignment.AssignedAt.UTC().Format(time.RFC3339),
+			UnassignedAt:       timeToDTO(assignment.UnassignedAt),
+			Reason:             string(assignment.Reason),
+			ReplacedReviewerID: assignment.ReplacedReviewerID,
+			ActorID:            assignment.ActorID,
+		}
+	}
+	return result
 }
 
 func prToShortDTO(pr *core.PullRequest) (PullRequestShortDTO, error) {
@@ -109,16 +426,21 @@ func prToShortDTO(pr *core.PullRequest) (PullRequestShortDTO, error) {
 	}
 
 	status := string(pr.Status)
-	if status != string(core.PullRequestStatusOpen) && status != string(core.PullRequestStatusMerged) {
+	if !pr.Status.IsValid() {
 		return PullRequestShortDTO{}, ErrInvalidStatus
 	}
 
-	return PullRequestShortDTO{
+	dto := PullRequestShortDTO{
 		PullRequestID:   pr.ID,
 		PullRequestName: pr.Name,
 		AuthorID:        pr.AuthorID,
 		Status:          status,
-	}, nil
+		MergedAt:        timeToDTO(pr.MergedAt),
+	}
+	if !pr.CreatedAt.IsZero() {
+		dto.CreatedAt = timeToDTO(&pr.CreatedAt)
+	}
+	return dto, nil
 }
 
 func prsToShortDTOs(prs []*core.PullRequest) ([]PullRequestShortDTO, error) {
@@ -137,22 +459,201 @@ func prsToShortDTOs(prs []*core.PullRequest) ([]PullRequestShortDTO, error) {
 	return result, nil
 }
 
-func statisticsToDTO(stats map[string]int) StatisticsResponseDTO {
-	byUsers := make([]UserStatisticDTO, 0, len(stats))
-	totalAssignments := 0
+// statisticsFilterFromQuery разбирает параметры GET /statistics. Время задаётся в RFC 3339.
+func statisticsFilterFromQuery(query url.Values) (core.StatisticsFilter, error) {
+	filter := core.StatisticsFilter{TeamName: query.Get("team_name")}
 
-	for userID, count := range stats {
-		byUsers = append(byUsers, UserStatisticDTO{
-			UserID:           userID,
-			AssignmentsCount: count,
-		})
-		totalAssignments += count
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return core.StatisticsFilter{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", ErrInvalidQuery, name)
+		}
+		*target = &parsed
+	}
+	return filter, nil
+}
+
+func cycleTimeToDTO(cycleTime core.CycleTime) CycleTimeDTO {
+	return CycleTimeDTO{
+		Count:          cycleTime.Count,
+		AverageSeconds: cycleTime.Average.Seconds(),
+		P50Seconds:     cycleTime.P50.Seconds(),
+		P90Seconds:     cycleTime.P90.Seconds(),
+	}
+}
+
+func statisticsToDTO(stats *core.Statistics) StatisticsResponseDTO {
+	byUsers := make([]UserStatisticDTO, len(stats.Users))
+	for i, user := range stats.Users {
+		byUsers[i] = UserStatisticDTO{
+			UserID:             user.UserID,
+			AssignmentsCount:   user.Assignments,
+			ReassignmentsCount: user.Reassignments,
+			OpenPRs:            user.OpenPRs,
+			MergedPRs:          user.MergedPRs,
+			TimeToMerge:        cycleTimeToDTO(user.TimeToMerge),
+		}
+	}
+
+	byTeams := make([]TeamStatisticDTO, len(stats.Teams))
+	for i, team := range stats.Teams {
+		byTeams[i] = TeamStatisticDTO{
+			TeamName:    team.TeamName,
+			OpenPRs:     team.OpenPRs,
+			MergedPRs:   team.MergedPRs,
+			TimeToMerge: cycleTimeToDTO(team.TimeToMerge),
+		}
+	}
+
+	perWeek := make([]WeeklyAssignmentsDTO, len(stats.AssignmentsPerWeek))
+	for i, week := range stats.AssignmentsPerWeek {
+		perWeek[i] = WeeklyAssignmentsDTO{
+			WeekStart:        week.WeekStart.Format(time.DateOnly),
+			AssignmentsCount: week.Assignments,
+		}
+	}
+
+	byReason := make(map[string]int, len(stats.Reassignments))
+	totalReassignments := 0
+	for reason, count := range stats.Reassignments {
+		byReason[string(reason)] = count
+		totalReassignments += count
 	}
 
 	return StatisticsResponseDTO{
-		ByUsers:          byUsers,
-		TotalAssignments: totalAssignments,
+		ByUsers:               byUsers,
+		ByTeams:               byTeams,
+		AssignmentsPerWeek:    perWeek,
+		TotalAssignments:      stats.Assignments,
+		TotalReassignments:    totalReassignments,
+		ReassignmentsByReason: byReason,
+		OpenPRs:               stats.OpenPRs,
+		MergedPRs:             stats.MergedPRs,
+		TimeToMerge:           cycleTimeToDTO(stats.TimeToMerge),
+	}
+}
+
+func webhookFromDTO(dto CreateWebhookDTO) *core.WebhookSubscription {
+	eventTypes := make([]core.EventType, len(dto.EventTypes))
+	for i, eventType := range dto.EventTypes {
+		eventTypes[i] = core.EventType(eventType)
+	}
+	return &core.WebhookSubscription{
+		URL:        dto.URL,
+		Secret:     dto.Secret,
+		EventTypes: eventTypes,
+	}
+}
+
+func webhookToDTO(subscription core.WebhookSubscription) WebhookDTO {
+	eventTypes := make([]string, len(subscription.EventTypes))
+	for i, eventType := range subscription.EventTypes {
+		eventTypes[i] = string(eventType)
+	}
+	return WebhookDTO{
+		WebhookID:  subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func tokenToDTO(token core.APIToken) TokenDTO {
+	return TokenDTO{
+		TokenID:   token.ID,
+		Name:      token.Name,
+		Role:      string(token.Role),
+		UserID:    token.UserID,
+		CreatedAt: token.CreatedAt.UTC().Format(time.RFC3339),
+		RevokedAt: timeToDTO(token.RevokedAt),
+	}
+}
+
+func auditFilterFromQuery(query url.Values) (core.AuditFilter, error) {
+	filter := core.AuditFilter{
+		ActorID:   query.Get("actor_id"),
+		Operation: core.AuditOperation(query.Get("operation")),
+		EntityID:  query.Get("entity_id"),
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return core.AuditFilter{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", ErrInvalidQuery, name)
+		}
+		*target = &parsed
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return core.AuditFilter{}, fmt.Errorf("%w: limit must be a positive integer", ErrInvalidQuery)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		beforeID, err := decodeAuditCursor(value)
+		if err != nil {
+			return core.AuditFilter{}, err
+		}
+		filter.BeforeID = beforeID
+	}
+	return filter, nil
+}
+
+func encodeAuditCursor(beforeID int64) string {
+	if beforeID == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(beforeID, 10)))
+}
+
+func decodeAuditCursor(value string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	beforeID, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || beforeID <= 0 {
+		return 0, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return beforeID, nil
+}
+
+func auditRecordsToDTOs(records []core.AuditRecord) []AuditRecordDTO {
+	result := make([]AuditRecordDTO, len(records))
+	for i, record := range records {
+		result[i] = AuditRecordDTO{
+			AuditID:   record.ID,
+			ActorID:   record.ActorID,
+			TokenID:   record.TokenID,
+			TokenName: record.TokenName,
+			Role:      string(record.Role),
+			Operation: string(record.Operation),
+			EntityID:  record.EntityID,
+			Payload:   record.Payload,
+			Result:    record.Result,
+			CreatedAt: record.CreatedAt.UTC().Format(time.RFC3339),
+		}
 	}
+	return result
 }
 
 func mapErrorToCode(err error) (string, bool) {
@@ -163,12 +664,56 @@ func mapErrorToCode(err error) (string, bool) {
 		return "PR_EXISTS", true
 	case errors.Is(err, core.ErrPRMerged):
 		return "PR_MERGED", true
+	case errors.Is(err, core.ErrPRClosed):
+		return "PR_CLOSED", true
+	case errors.Is(err, core.ErrMergeBlocked):
+		return "MERGE_BLOCKED", true
+	case errors.Is(err, core.ErrInvalidOverride):
+		return "INVALID_OVERRIDE", true
 	case errors.Is(err, core.ErrNotAssigned):
 		return "NOT_ASSIGNED", true
 	case errors.Is(err, core.ErrNoCandidate):
 		return "NO_CANDIDATE", true
 	case errors.Is(err, core.ErrNotFound):
 		return "NOT_FOUND", true
+	case errors.Is(err, core.ErrInvalidSettings):
+		return "INVALID_SETTINGS", true
+	case errors.Is(err, core.ErrInvalidCodeOwners):
+		return "INVALID_CODE_OWNERS", true
+	case errors.Is(err, core.ErrInvalidUnavailability):
+		return "INVALID_UNAVAILABILITY", true
+	case errors.Is(err, core.ErrInvalidReviewState):
+		return "INVALID_REVIEW_STATE", true
+	case errors.Is(err, core.ErrInvalidWebhook):
+		return "INVALID_WEBHOOK", true
+	case errors.Is(err, core.ErrWebhooksDisabled):
+		return "WEBHOOKS_DISABLED", true
+	case errors.Is(err, core.ErrHistoryDisabled):
+		return "HISTORY_DISABLED", true
+	case errors.Is(err, core.ErrInvalidFilter):
+		return "INVALID_FILTER", true
+	case errors.Is(err, core.ErrInvalidAccount):
+		return "INVALID_ACCOUNT", true
+	case errors.Is(err, core.ErrUnknownAccount):
+		return "UNKNOWN_ACCOUNT", true
+	case errors.Is(err, core.ErrIntegrationsDisabled):
+		return "INTEGRATIONS_DISABLED", true
+	case errors.Is(err, core.ErrUnauthorized):
+		return "UNAUTHORIZED", true
+	case errors.Is(err, core.ErrForbidden):
+		return "FORBIDDEN", true
+	case errors.Is(err, core.ErrInvalidToken):
+		return "INVALID_TOKEN", true
+	case errors.Is(err, core.ErrAuthDisabled):
+		return "AUTH_DISABLED", true
+	case errors.Is(err, core.ErrAuditDisabled):
+		return "AUDIT_DISABLED", true
+	case errors.Is(err, core.ErrIdempotencyConflict):
+		return "IDEMPOTENCY_CONFLICT", true
+	case errors.Is(err, core.ErrIdempotencyInProgress):
+		return "IDEMPOTENCY_IN_PROGRESS", true
+	case errors.Is(err, core.ErrInvalidIdempotencyKey):
+		return "INVALID_IDEMPOTENCY_KEY", true
 	default:
 		return "", false
 	}
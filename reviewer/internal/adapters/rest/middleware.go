@@ -1,11 +1,164 @@
 package rest
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"pr-reviewer/internal/core"
+)
+
+// ActorHeader - заголовок с ID пользователя, от имени которого выполняется запрос.
+const ActorHeader = "X-Actor-ID"
+
+// ActorMiddleware передаёт пользователя из ActorHeader в контекст запроса для истории назначений.
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actorID := r.Header.Get(ActorHeader); actorID != "" {
+			r = r.WithContext(core.WithActor(r.Context(), actorID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthMiddleware требует у всех запросов, кроме publicPaths, заголовок Authorization: Bearer
+// с токеном API и передаёт его владельца в контекст запроса. Действия токена пользователя
+// записываются от его имени: ActorHeader учитывается только у токенов без пользователя,
+// поэтому AuthMiddleware должен выполняться после ActorMiddleware.
+func AuthMiddleware(log *slog.Logger, service *core.Service, publicPaths ...string) func(http.Handler) http.Handler {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", core.ErrUnauthorized.Error())
+				return
+			}
+
+			principal, err := service.Authenticate(r.Context(), token)
+			if err != nil {
+				if errors.Is(err, core.ErrUnauthorized) {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+					return
+				}
+				log.Error("failed to authenticate request", "error", err)
+				writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+				return
+			}
+
+			ctx := core.WithPrincipal(r.Context(), principal)
+			if principal.UserID != "" {
+				ctx = core.WithActor(ctx, principal.UserID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+const (
+	// IdempotencyHeader - ключ, с которым повтор POST-запроса получает сохранённый ответ
+	// вместо повторного выполнения.
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader отмечает ответ, сохранённый для предыдущего запроса с тем же ключом.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
 )
 
+// IdempotencyMiddleware сохраняет ответы на POST-запросы с IdempotencyHeader и отдаёт их
+// на повторы с тем же ключом. Повтор с другим маршрутом или телом получает 409, как и
+// повтор, пришедший до завершения первого запроса. Ответы 5xx не сохраняются: ключ
+// освобождается, и повтор выполняется заново. Ключи разделены по токенам, поэтому
+// IdempotencyMiddleware должен выполняться после AuthMiddleware, а чтобы повторы и 409
+// попадали в метрики и трассы - внутри MetricsMiddleware и TracingMiddleware.
+func IdempotencyMiddleware(log *slog.Logger, service *core.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Error("failed to read request body", "error", err)
+				writeError(w, http.StatusBadRequest, "BAD_REQUEST", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			stored, err := service.BeginIdempotentRequest(r.Context(), key, r.Method+" "+r.URL.RequestURI(), body)
+			if err != nil {
+				errorCode, _ := mapErrorToCode(err)
+				switch errorCode {
+				case "INVALID_IDEMPOTENCY_KEY":
+					writeError(w, http.StatusBadRequest, errorCode, err.Error())
+					return
+				case "IDEMPOTENCY_CONFLICT", "IDEMPOTENCY_IN_PROGRESS":
+					writeError(w, http.StatusConflict, errorCode, err.Error())
+					return
+				}
+				log.Error("failed to check idempotency key", "error", err)
+				writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+				return
+			}
+			if stored != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			// ответ сохраняется и после отключения клиента: именно он и придёт с повтором
+			ctx := context.WithoutCancel(r.Context())
+			rw := &recordingWriter{responseWriter: responseWriter{ResponseWriter: w, statusCode: http.StatusOK}}
+			defer func() {
+				if p := recover(); p != nil {
+					if err := service.AbortIdempotentRequest(ctx, key); err != nil {
+						log.Error("failed to release idempotency key", "error", err)
+					}
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+
+			if rw.statusCode >= http.StatusInternalServerError {
+				if err := service.AbortIdempotentRequest(ctx, key); err != nil {
+					log.Error("failed to release idempotency key", "error", err)
+				}
+				return
+			}
+			response := core.IdempotentResponse{StatusCode: rw.statusCode, Body: rw.body.Bytes()}
+			if err := service.CompleteIdempotentRequest(ctx, key, response); err != nil {
+				log.Error("failed to store idempotent response", "error", err)
+			}
+		})
+	}
+}
+
 func LoggingMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +180,117 @@ func LoggingMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// HTTPMetrics учитывает обработанные запросы. route - шаблон маршрута ServeMux без метода,
+// чтобы число рядов метрик не зависело от параметров запроса.
+type HTTPMetrics interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// UnmatchedRoute - маршрут запросов, для которых в ServeMux нет обработчика.
+const UnmatchedRoute = "unmatched"
+
+// MetricsMiddleware учитывает все ответы, включая отказы AuthMiddleware и IdempotencyMiddleware,
+// поэтому должен выполняться до них. Шаблон маршрута ему передаёт RouteMiddleware; без него
+// MetricsMiddleware должен оборачивать ServeMux напрямую: шаблон маршрута ServeMux
+// записывает в тот же *http.Request, который получил.
+func MetricsMiddleware(metrics HTTPMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			r, info := withRoute(r)
+
+			next.ServeHTTP(rw, r)
+
+			metrics.ObserveRequest(r.Method, info.route(r), rw.statusCode, time.Since(start))
+		})
+	}
+}
+
+// TracingMiddleware начинает серверный спан запроса, продолжая трассу из заголовка traceparent,
+// и возвращает контекст спана в том же заголовке ответа. Как и MetricsMiddleware, должен
+// выполняться до AuthMiddleware и IdempotencyMiddleware и получает шаблон маршрута для
+// имени спана от RouteMiddleware или от ServeMux, который оборачивает напрямую.
+func TracingMiddleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer("pr-reviewer/internal/adapters/rest")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+		defer span.End()
+		propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		r, info := withRoute(r.WithContext(ctx))
+
+		next.ServeHTTP(rw, r)
+
+		route := info.route(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", rw.statusCode),
+		)
+		if rw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+		}
+	})
+}
+
+// RouteMiddleware передаёт MetricsMiddleware и TracingMiddleware шаблон маршрута mux, когда
+// между ними и ServeMux есть middleware, меняющие контекст запроса: ServeMux записывает шаблон
+// только в свою копию *http.Request. Шаблон определяется после обработки запроса, в том числе
+// для запросов, на которые ответили до ServeMux, например 401 от AuthMiddleware.
+func RouteMiddleware(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+				_, info.pattern = mux.Handler(r)
+				info.resolved = true
+			}
+		})
+	}
+}
+
+type routeKey struct{}
+
+// routeInfo - шаблон маршрута, который RouteMiddleware передаёт внешним middleware.
+type routeInfo struct {
+	pattern  string
+	resolved bool
+}
+
+// withRoute добавляет в контекст запроса routeInfo или возвращает уже добавленный.
+func withRoute(r *http.Request) (*http.Request, *routeInfo) {
+	if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+		return r, info
+	}
+	info := &routeInfo{}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, info)), info
+}
+
+// route возвращает шаблон маршрута без метода: от RouteMiddleware или тот, который ServeMux
+// записал в запрос.
+func (info *routeInfo) route(r *http.Request) string {
+	pattern := r.Pattern
+	if info.resolved {
+		pattern = info.pattern
+	}
+	if pattern == "" {
+		return UnmatchedRoute
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -36,3 +300,14 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// recordingWriter дополнительно копирует тело ответа для IdempotencyMiddleware.
+type recordingWriter struct {
+	responseWriter
+	body bytes.Buffer
+}
+
+func (rw *recordingWriter) Write(data []byte) (int, error) {
+	rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
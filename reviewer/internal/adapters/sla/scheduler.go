@@ -0,0 +1,54 @@
+package sla
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+// Scheduler периодически обрабатывает ревью, просроченные относительно SLA команды автора.
+type Scheduler struct {
+	log      *slog.Logger
+	service  *core.Service
+	interval time.Duration
+	action   core.SLAAction
+}
+
+func NewScheduler(log *slog.Logger, service *core.Service, interval time.Duration, action core.SLAAction) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		service:  service,
+		interval: interval,
+		action:   action,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			s.log.Error("review SLA check failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	report, err := s.service.HandleOverdueReviews(ctx, s.action)
+	if err != nil {
+		return err
+	}
+	if report.Escalated > 0 || report.Reassigned > 0 {
+		s.log.Info("overdue reviews handled", "escalated", report.Escalated, "reassigned", report.Reassigned)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package sla_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/adapters/sla"
+	"pr-reviewer/internal/core"
+)
+
+func setup(t *testing.T) (*core.Service, *memory.Storage) {
+	t.Helper()
+
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithTransactor(storage))
+	ctx := context.Background()
+
+	members := []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+		{ID: "u3", Username: "Carol", IsActive: true},
+		{ID: "u4", Username: "Dave", IsActive: true},
+		{ID: "u5", Username: "Eve", IsActive: true},
+	}
+	reviewSLA := time.Nanosecond
+	err := service.CreateTeamWithSettings(ctx, "backend", members, core.TeamSettings{ReviewSLA: reviewSLA})
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	return service, storage
+}
+
+func TestScheduler_RunOnceReassignsOverdueReviews(t *testing.T) {
+	service, storage := setup(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	before, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	scheduler := sla.NewScheduler(logger, service, time.Minute, core.SLAActionReassign)
+	if err := scheduler.RunOnce(ctx); err != nil {
+		t.Fatalf("failed to run SLA check: %v", err)
+	}
+
+	after, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if len(after.ReviewersIDs) != len(before.ReviewersIDs) {
+		t.Fatalf("expected %d reviewers after reassignment, got %v", len(before.ReviewersIDs), after.ReviewersIDs)
+	}
+	// вторая замена может вернуть ревьювера, снятого первой, поэтому проверяется время назначения
+	var lastAssigned time.Time
+	for _, reviewerID := range before.ReviewersIDs {
+		if assignedAt := before.Review(reviewerID).AssignedAt; assignedAt.After(lastAssigned) {
+			lastAssigned = assignedAt
+		}
+	}
+	for _, reviewerID := range after.ReviewersIDs {
+		if !after.Review(reviewerID).AssignedAt.After(lastAssigned) {
+			t.Errorf("expected overdue reviews to be reassigned, got %v after %v", after.ReviewersIDs, before.ReviewersIDs)
+		}
+	}
+}
+
+func TestScheduler_RunStopsWithContext(t *testing.T) {
+	service, storage := setup(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	time.Sleep(time.Millisecond)
+
+	// первая проверка выполняется сразу, затем Run выходит по отменённому контексту
+	done := make(chan struct{})
+	go func() {
+		sla.NewScheduler(logger, service, time.Hour, core.SLAActionEscalate).Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to stop after context cancellation")
+	}
+
+	pr, err := storage.PR.GetByID(context.Background(), "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	for _, review := range pr.Reviews {
+		if review.EscalatedAt == nil {
+			t.Errorf("expected review of %s to be escalated", review.ReviewerID)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone - спаны не записываются, но контекст traceparent передаётся дальше.
+	ExporterNone = "none"
+	// ExporterStdout - спаны пишутся в JSON в out.
+	ExporterStdout = "stdout"
+	// ExporterMemory - спаны остаются в памяти процесса, для тестов.
+	ExporterMemory = "memory"
+)
+
+// Provider настраивает глобальные TracerProvider и propagator W3C Trace Context OpenTelemetry,
+// через которые спаны пишут REST, core и db.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	// Memory хранит завершённые спаны при экспортере memory, иначе nil.
+	Memory *tracetest.InMemoryExporter
+}
+
+func Setup(exporter, serviceName string, out io.Writer) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	p := &Provider{}
+	var processor sdktrace.SpanProcessor
+	switch exporter {
+	case ExporterNone:
+		return p, nil
+	case ExporterStdout:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, err
+		}
+		processor = sdktrace.NewBatchSpanProcessor(spanExporter)
+	case ExporterMemory:
+		p.Memory = tracetest.NewInMemoryExporter()
+		processor = sdktrace.NewSimpleSpanProcessor(p.Memory)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(p.provider)
+	return p, nil
+}
+
+// Close дописывает накопленные спаны и останавливает провайдер.
+func (p *Provider) Close() error {
+	if p.provider == nil {
+		return nil
+	}
+	return p.provider.Shutdown(context.Background())
+}
+
+// Tracer реализует core.Tracer поверх глобального TracerProvider.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{tracer: otel.Tracer("pr-reviewer/internal/core")}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// Transport пишет клиентский спан на каждый исходящий запрос и передаёт его контекст
+// получателю в заголовке traceparent.
+type Transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{base: base, tracer: otel.Tracer("pr-reviewer/internal/adapters/tracing")}
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(r.Context(), r.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", r.Method),
+		attribute.String("server.address", r.URL.Host),
+	))
+	defer span.End()
+
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/adapters/rest"
+	"pr-reviewer/internal/adapters/tracing"
+	"pr-reviewer/internal/core"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func setupMemory(t *testing.T) *tracing.Provider {
+	t.Helper()
+	provider, err := tracing.Setup(tracing.ExporterMemory, "pr-reviewer-test", io.Discard)
+	if err != nil {
+		t.Fatalf("failed to set up tracing: %v", err)
+	}
+	t.Cleanup(func() { _ = provider.Close() })
+	return provider
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found among %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestTracing_PropagatesRequestThroughService(t *testing.T) {
+	provider := setupMemory(t)
+
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithTracer(tracing.NewTracer()))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	err := service.CreateTeam(ctx, "backend", []core.User{
+		{ID: "u1", Username: "Alice", IsActive: true},
+		{ID: "u2", Username: "Bob", IsActive: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	provider.Memory.Reset()
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /pullRequest/create", rest.CreatePRHandler(logger, service))
+	handler := rest.TracingMiddleware(mux)
+
+	body, _ := json.Marshal(rest.CreatePRDTO{PullRequestID: "pr-1", PullRequestName: "Feature", AuthorID: "u1"})
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewReader(body))
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d, body: %s", w.Code, w.Body.String())
+	}
+
+	spans := provider.Memory.GetSpans()
+	server := spanByName(t, spans, "POST /pullRequest/create")
+	if server.SpanContext.TraceID().String() != parentTraceID || server.Parent.SpanID().String() != parentSpanID {
+		t.Errorf("expected server span to continue incoming trace, got trace %s parent %s",
+			server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	operation := spanByName(t, spans, "Service.CreatePR")
+	if operation.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("expected Service.CreatePR to be a child of the server span")
+	}
+
+	traceparent := w.Header().Get("traceparent")
+	if !strings.Contains(traceparent, parentTraceID) || !strings.Contains(traceparent, server.SpanContext.SpanID().String()) {
+		t.Errorf("expected response traceparent with server span, got %q", traceparent)
+	}
+
+	// ошибка операции отмечается в спане сервиса
+	provider.Memory.Reset()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewReader(body)))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d, body: %s", w.Code, w.Body.String())
+	}
+	if failed := spanByName(t, provider.Memory.GetSpans(), "Service.CreatePR"); len(failed.Events) == 0 {
+		t.Errorf("expected error event on Service.CreatePR span")
+	}
+}
+
+func TestTransport_InjectsTraceparent(t *testing.T) {
+	provider := setupMemory(t)
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, end := tracing.NewTracer().Start(context.Background(), "delivery")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, http.NoBody)
+	client := &http.Client{Transport: tracing.NewTransport(http.DefaultTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	end(nil)
+
+	spans := provider.Memory.GetSpans()
+	outgoing := spanByName(t, spans, http.MethodPost)
+	parent := spanByName(t, spans, "delivery")
+	if outgoing.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Errorf("expected client span to be a child of delivery span")
+	}
+	if !strings.Contains(received, outgoing.SpanContext.TraceID().String()) ||
+		!strings.Contains(received, outgoing.SpanContext.SpanID().String()) {
+		t.Errorf("expected traceparent of client span, got %q", received)
+	}
+}
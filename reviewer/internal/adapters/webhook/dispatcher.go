@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+const (
+	SignatureHeader = "X-PR-Reviewer-Signature"
+	EventHeader     = "X-PR-Reviewer-Event"
+	DeliveryHeader  = "X-PR-Reviewer-Delivery"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	BatchSize    int
+	Lease        time.Duration
+}
+
+// Dispatcher переносит события из outbox в доставки и отправляет их подписчикам.
+// Доставка "at least once": подписчик должен быть готов к повторам по event_id.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  core.WebhookStore
+	client *http.Client
+	cfg    Config
+	now    func() time.Time
+}
+
+func NewDispatcher(log *slog.Logger, store core.WebhookStore, client *http.Client, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		log:    log,
+		store:  store,
+		client: client,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DispatchOnce(ctx); err != nil {
+			d.log.Error("webhook dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) DispatchOnce(ctx context.Context) error {
+	if _, err := d.store.ScheduleDeliveries(ctx, d.cfg.BatchSize); err != nil {
+		return fmt.Errorf("schedule deliveries: %w", err)
+	}
+
+	deliveries, err := d.store.ClaimDueDeliveries(ctx, d.now(), d.cfg.Lease, d.cfg.BatchSize)
+	if err != nil {
+		return fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		if err := d.deliver(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery core.WebhookDelivery) error {
+	attempts := delivery.Attempts + 1
+
+	sendErr := d.send(ctx, delivery)
+	if sendErr == nil {
+		return d.store.MarkDelivered(ctx, delivery.ID, attempts, d.now())
+	}
+
+	var nextAttemptAt *time.Time
+	if attempts < d.cfg.MaxAttempts {
+		next := d.now().Add(d.backoff(attempts))
+		nextAttemptAt = &next
+	}
+
+	d.log.Warn("webhook delivery failed",
+		"delivery_id", delivery.ID,
+		"url", delivery.Subscription.URL,
+		"attempts", attempts,
+		"gave_up", nextAttemptAt == nil,
+		"error", sendErr,
+	)
+	return d.store.MarkFailed(ctx, delivery.ID, attempts, nextAttemptAt, sendErr.Error())
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery core.WebhookDelivery) error {
+	body, err := json.Marshal(toPayload(delivery.Event))
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event.Type))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Subscription.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff - экспоненциальная задержка перед попыткой attempts+1.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
+
+// Sign возвращает подпись тела в формате "sha256=<hex HMAC-SHA256>".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/adapters/webhook"
+	"pr-reviewer/internal/core"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	payloads []webhook.Payload
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get(webhook.SignatureHeader) != webhook.Sign("secret", body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload webhook.Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setup(t *testing.T, failures int) (*core.Service, *webhook.Dispatcher, *receiver) {
+	t.Helper()
+
+	recv := &receiver{failures: failures}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage),
+		core.WithEvents(storage.Event, storage.Webhook),
+	)
+
+	ctx := context.Background()
+	users := []core.User{
+		{ID: "u1", Username: "u1", TeamName: "backend", IsActive: true},
+		{ID: "u2", Username: "u2", TeamName: "backend", IsActive: true},
+		{ID: "u3", Username: "u3", TeamName: "backend", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "backend", users); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	if err := service.RegisterWebhook(ctx, &core.WebhookSubscription{URL: server.URL, Secret: "secret"}); err != nil {
+		t.Fatalf("failed to register webhook: %v", err)
+	}
+
+	dispatcher := webhook.NewDispatcher(slog.New(slog.DiscardHandler), storage.Webhook, server.Client(), webhook.Config{
+		PollInterval: time.Millisecond,
+		MaxAttempts:  3,
+		BatchSize:    10,
+		Lease:        time.Minute,
+	})
+	return service, dispatcher, recv
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	service, dispatcher, recv := setup(t, 0)
+	ctx := context.Background()
+
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.MergePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to merge PR: %v", err)
+	}
+	if err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+
+	if len(recv.payloads) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(recv.payloads))
+	}
+	if recv.payloads[0].Type != string(core.EventPRCreated) || recv.payloads[1].Type != string(core.EventPRMerged) {
+		t.Errorf("unexpected event order: %s, %s", recv.payloads[0].Type, recv.payloads[1].Type)
+	}
+	if recv.payloads[1].PullRequest.Status != string(core.PullRequestStatusMerged) {
+		t.Errorf("expected merged status in payload, got %s", recv.payloads[1].PullRequest.Status)
+	}
+
+	// повторный проход ничего не отправляет
+	if err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(recv.payloads) != 2 {
+		t.Errorf("expected no duplicate deliveries, got %d", len(recv.payloads))
+	}
+}
+
+func TestDispatcher_RetriesFailedDelivery(t *testing.T) {
+	service, dispatcher, recv := setup(t, 1)
+	ctx := context.Background()
+
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	if err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(recv.payloads) != 0 {
+		t.Fatalf("expected first attempt to fail, got %d deliveries", len(recv.payloads))
+	}
+
+	// нулевой backoff: повтор доступен сразу
+	if err := dispatcher.DispatchOnce(ctx); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if len(recv.payloads) != 1 || recv.payloads[0].PullRequest.PullRequestID != "pr-1" {
+		t.Fatalf("expected retried delivery of pr-1, got %+v", recv.payloads)
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	service, dispatcher, recv := setup(t, 10)
+	ctx := context.Background()
+
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	for range 5 {
+		if err := dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+	if recv.failures != 7 {
+		t.Errorf("expected exactly 3 attempts, receiver saw %d", 10-recv.failures)
+	}
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+type pullRequestPayload struct {
+	PullRequestID     string          `json:"pull_request_id"`
+	PullRequestName   string          `json:"pull_request_name"`
+	AuthorID          string          `json:"author_id"`
+	Status            string          `json:"status"`
+	AssignedReviewers []string        `json:"assigned_reviewers"`
+	Reviews           []reviewPayload `json:"reviews"`
+	RequiredReviewers int             `json:"required_reviewers"`
+	CreatedAt         *time.Time      `json:"created_at,omitempty"`
+	MergedAt          *time.Time      `json:"merged_at,omitempty"`
+}
+
+type reviewPayload struct {
+	ReviewerID string    `json:"reviewer_id"`
+	State      string    `json:"state"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// запасная команда ревьювера, если он назначен не из команды автора
+	FallbackTeam string `json:"fallback_team,omitempty"`
+	// шаблон правила владения кодом, по которому назначен ревьювер
+	OwnerPattern string `json:"owner_pattern,omitempty"`
+	// время эскалации просроченного ревью
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+}
+
+type reassignmentPayload struct {
+	OldReviewerID string  `json:"old_reviewer_id"`
+	NewReviewerID *string `json:"new_reviewer_id"`
+}
+
+type escalationPayload struct {
+	ReviewerID string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+	DueAt      time.Time `json:"due_at"`
+}
+
+// Payload - тело запроса, которое получает подписчик.
+type Payload struct {
+	EventID      int64                `json:"event_id"`
+	Type         string               `json:"type"`
+	OccurredAt   time.Time            `json:"occurred_at"`
+	PullRequest  pullRequestPayload   `json:"pull_request"`
+	Reassignment *reassignmentPayload `json:"reassignment,omitempty"`
+	Escalation   *escalationPayload   `json:"escalation,omitempty"`
+}
+
+func toPayload(event core.Event) Payload {
+	pr := event.PullRequest
+	reviewers := pr.ReviewersIDs
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+
+	payload := Payload{
+		EventID:    event.ID,
+		Type:       string(event.Type),
+		OccurredAt: event.OccurredAt.UTC(),
+		PullRequest: pullRequestPayload{
+			PullRequestID:     pr.ID,
+			PullRequestName:   pr.Name,
+			AuthorID:          pr.AuthorID,
+			Status:            string(pr.Status),
+			AssignedReviewers: reviewers,
+			Reviews:           []reviewPayload{},
+			RequiredReviewers: pr.RequiredReviewers,
+		},
+	}
+	if !pr.CreatedAt.IsZero() {
+		createdAt := pr.CreatedAt.UTC()
+		payload.PullRequest.CreatedAt = &createdAt
+	}
+	if pr.MergedAt != nil {
+		mergedAt := pr.MergedAt.UTC()
+		payload.PullRequest.MergedAt = &mergedAt
+	}
+
+	for _, reviewerID := range reviewers {
+		review := pr.Review(reviewerID)
+		reviewItem := reviewPayload{
+			ReviewerID:   reviewerID,
+			State:        string(review.State),
+			UpdatedAt:    review.UpdatedAt.UTC(),
+			FallbackTeam: review.FallbackTeam,
+			OwnerPattern: review.OwnerPattern,
+		}
+		if review.EscalatedAt != nil {
+			escalatedAt := review.EscalatedAt.UTC()
+			reviewItem.EscalatedAt = &escalatedAt
+		}
+		payload.PullRequest.Reviews = append(payload.PullRequest.Reviews, reviewItem)
+	}
+
+	if event.Reassignment != nil {
+		reassignment := &reassignmentPayload{OldReviewerID: event.Reassignment.OldReviewerID}
+		if event.Reassignment.NewReviewerID != "" {
+			newID := event.Reassignment.NewReviewerID
+			reassignment.NewReviewerID = &newID
+		}
+		payload.Reassignment = reassignment
+	}
+
+	if event.Escalation != nil {
+		payload.Escalation = &escalationPayload{
+			ReviewerID: event.Escalation.ReviewerID,
+			AssignedAt: event.Escalation.AssignedAt.UTC(),
+			DueAt:      event.Escalation.DueAt.UTC(),
+		}
+	}
+	return payload
+}
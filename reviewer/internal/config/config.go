@@ -6,15 +6,97 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
 type HTTPConfig struct {
 	Address string        `yaml:"address" env:"PR_REVIEWER_ADDRESS" env-default:"localhost:8080"`
 	Timeout time.Duration `yaml:"timeout" env:"PR_REVIEWER_TIMEOUT" env-default:"10s"`
 }
 
+type ReviewersConfig struct {
+	DefaultStrategy string            `yaml:"default_strategy" env:"REVIEWER_STRATEGY" env-default:"least_loaded"`
+	TeamStrategies  map[string]string `yaml:"team_strategies"`
+}
+
+type WebhookConfig struct {
+	Enabled      bool          `yaml:"enabled" env:"WEBHOOKS_ENABLED" env-default:"true"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" env-default:"1s"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"5s"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" env:"WEBHOOKS_BASE_BACKOFF" env-default:"1s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF" env-default:"5m"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOKS_BATCH_SIZE" env-default:"100"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOKS_LEASE" env-default:"30s"`
+}
+
+type SLAConfig struct {
+	Enabled  bool          `yaml:"enabled" env:"SLA_ENABLED" env-default:"true"`
+	Interval time.Duration `yaml:"interval" env:"SLA_INTERVAL" env-default:"1m"`
+	// escalate - событие pr.review_overdue, reassign - замена ревьювера с эскалацией при отсутствии кандидата
+	Action string `yaml:"action" env:"SLA_ACTION" env-default:"escalate"`
+}
+
+type MetricsConfig struct {
+	// включает GET /metrics и сбор метрик
+	Enabled bool `yaml:"enabled" env:"METRICS_ENABLED" env-default:"true"`
+}
+
+type TracingConfig struct {
+	// none, stdout или memory (спаны в памяти процесса, для тестов)
+	Exporter    string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	ServiceName string `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"pr-reviewer"`
+}
+
+type AuthConfig struct {
+	// при выключенной аутентификации API открыт без токенов и ролей
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
+	// токен администратора, который регистрируется при старте; должен начинаться с prr_.
+	// Без него сервис с аутентификацией запускается, только если в хранилище уже есть токен администратора
+	AdminToken string `yaml:"admin_token" env:"AUTH_ADMIN_TOKEN"`
+}
+
+type IdempotencyConfig struct {
+	// включает заголовок Idempotency-Key у POST-запросов
+	Enabled bool `yaml:"enabled" env:"IDEMPOTENCY_ENABLED" env-default:"true"`
+	// сколько хранится ответ; после TTL запрос с тем же ключом выполняется заново
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	// через сколько освобождается ключ запроса, не сохранившего ответ; должна быть больше таймаута запроса
+	Lease time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE" env-default:"1m"`
+}
+
+type GitHubConfig struct {
+	// пустой секрет отключает эндпоинт
+	WebhookSecret string `yaml:"webhook_secret" env:"GITHUB_WEBHOOK_SECRET"`
+}
+
+type GitLabConfig struct {
+	// пустой токен отключает эндпоинт
+	WebhookToken string `yaml:"webhook_token" env:"GITLAB_WEBHOOK_TOKEN"`
+}
+
+type IntegrationsConfig struct {
+	GitHub GitHubConfig `yaml:"github"`
+	GitLab GitLabConfig `yaml:"gitlab"`
+}
+
 type Config struct {
-	LogLevel   string     `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
-	HTTPConfig HTTPConfig `yaml:"pr-reviewer"`
-	DBAddress  string     `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:5432"`
+	LogLevel   string          `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	HTTPConfig HTTPConfig      `yaml:"pr-reviewer"`
+	DBAddress  string          `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:5432"`
+	Storage    string          `yaml:"storage" env:"STORAGE" env-default:"postgres"`
+	Reviewers  ReviewersConfig `yaml:"reviewers"`
+	Webhooks   WebhookConfig   `yaml:"webhooks"`
+	SLA        SLAConfig       `yaml:"sla"`
+	Metrics    MetricsConfig   `yaml:"metrics"`
+	Tracing    TracingConfig   `yaml:"tracing"`
+	Auth       AuthConfig      `yaml:"auth"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+
+	Integrations IntegrationsConfig `yaml:"integrations"`
 }
 
 func MustLoad(path string) (*Config, error) {
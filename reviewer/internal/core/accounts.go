@@ -0,0 +1,33 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// ExternalAccount связывает логин во внешней системе (GitHub и т.п.) с пользователем сервиса.
+type ExternalAccount struct {
+	Provider string
+	Login    string
+	UserID   string
+}
+
+func (a *ExternalAccount) Validate() error {
+	if a.Provider == "" || a.Login == "" || a.UserID == "" {
+		return fmt.Errorf("%w: provider, login and user_id are required", ErrInvalidAccount)
+	}
+	if a.Provider != ProviderGitHub && a.Provider != ProviderGitLab {
+		return fmt.Errorf("%w: unknown provider %q", ErrInvalidAccount, a.Provider)
+	}
+	return nil
+}
+
+// normalizeLogin приводит логин к нижнему регистру: логины GitHub и GitLab регистронезависимы.
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditOperation - изменяющая операция сервиса, которая попадает в журнал аудита.
+type AuditOperation string
+
+const (
+	AuditOperationCreateTeam            AuditOperation = "create_team"
+	AuditOperationUpdateTeamSettings    AuditOperation = "update_team_settings"
+	AuditOperationSetOwnershipRules     AuditOperation = "set_ownership_rules"
+	AuditOperationSetUserActive         AuditOperation = "set_user_active"
+	AuditOperationSetUserUnavailability AuditOperation = "set_user_unavailability"
+	AuditOperationCreatePR              AuditOperation = "create_pr"
+	AuditOperationMergePR               AuditOperation = "merge_pr"
+	AuditOperationSubmitReview          AuditOperation = "submit_review"
+	AuditOperationClosePR               AuditOperation = "close_pr"
+	AuditOperationReopenPR              AuditOperation = "reopen_pr"
+	// запись на каждую замену ревьювера, ручную и автоматическую; причина замены - в Payload
+	AuditOperationReassignReviewer AuditOperation = "reassign_reviewer"
+	// запись на каждого пользователя, выключенного через /team/deactivateUsers
+	AuditOperationDeactivateUser AuditOperation = "deactivate_team_user"
+	AuditOperationCreateToken    AuditOperation = "create_token"
+	AuditOperationRevokeToken    AuditOperation = "revoke_token"
+)
+
+// SystemActorID - ActorID записей журнала о действиях, которые сервис выполняет сам,
+// без пользователя операции: замены ревьюверов по SLA.
+const SystemActorID = "system"
+
+func (o AuditOperation) IsValid() bool {
+	switch o {
+	case AuditOperationCreateTeam, AuditOperationUpdateTeamSettings, AuditOperationSetOwnershipRules,
+		AuditOperationSetUserActive, AuditOperationSetUserUnavailability, AuditOperationCreatePR,
+		AuditOperationMergePR, AuditOperationSubmitReview, AuditOperationClosePR, AuditOperationReopenPR,
+		AuditOperationReassignReviewer, AuditOperationDeactivateUser, AuditOperationCreateToken,
+		AuditOperationRevokeToken:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuditRecord - запись журнала аудита. Пишется в той же транзакции, что и изменение,
+// поэтому в журнал попадают только успешные операции.
+type AuditRecord struct {
+	ID int64
+	// кто выполнил операцию, пусто для действий без X-Actor-ID и токена пользователя.
+	// У токенов администратора и без аутентификации это заявленный X-Actor-ID
+	ActorID string
+	// токен, которым аутентифицирован запрос; пусто без аутентификации и у вызовов
+	// самого сервиса
+	TokenID   int64
+	TokenName string
+	Role      Role
+	Operation AuditOperation
+	// объект операции: имя команды, ID пользователя, PR или токена
+	EntityID string
+	// параметры запроса и итог операции в JSON
+	Payload   json.RawMessage
+	Result    json.RawMessage
+	CreatedAt time.Time
+}
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// AuditFilter - условия выборки журнала. Пустые поля не ограничивают выборку, интервал
+// времени полуоткрытый: [From, To).
+type AuditFilter struct {
+	ActorID   string
+	Operation AuditOperation
+	EntityID  string
+	From      *time.Time
+	To        *time.Time
+
+	// 0 означает DefaultAuditPageSize
+	Limit int
+	// записи с ID меньше BeforeID, 0 - с самой новой
+	BeforeID int64
+}
+
+func (f AuditFilter) Validate() error {
+	if f.Operation != "" && !f.Operation.IsValid() {
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidFilter, f.Operation)
+	}
+	if f.Limit < 0 || f.Limit > MaxAuditPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxAuditPageSize)
+	}
+	if f.BeforeID < 0 {
+		return fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// AuditPage - страница журнала от новых записей к старым. NextBeforeID равен 0 на последней странице.
+type AuditPage struct {
+	Records      []AuditRecord
+	NextBeforeID int64
+}
+
+// recordAudit пишет запись журнала от имени пользователя операции вместе с токеном запроса.
+// Вызывается внутри транзакции изменения, чтобы запись откатывалась вместе с ним.
+func (s *Service) recordAudit(ctx context.Context, operation AuditOperation, entityID string, payload, result any) error {
+	if s.audit == nil {
+		return nil
+	}
+
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	rawResult, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	principal, _ := PrincipalFromContext(ctx)
+	return s.audit.Append(ctx, &AuditRecord{
+		ActorID:   ActorFromContext(ctx),
+		TokenID:   principal.TokenID,
+		TokenName: principal.TokenName,
+		Role:      principal.Role,
+		Operation: operation,
+		EntityID:  entityID,
+		Payload:   rawPayload,
+		Result:    rawResult,
+		CreatedAt: time.Now(),
+	})
+}
+
+// GetAuditLog возвращает страницу журнала аудита, подходящую под фильтр.
+func (s *Service) GetAuditLog(ctx context.Context, filter AuditFilter) (_ AuditPage, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetAuditLog")
+	defer func() { end(err) }()
+
+	if s.audit == nil {
+		return AuditPage{}, ErrAuditDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return AuditPage{}, err
+	}
+	if err := filter.Validate(); err != nil {
+		return AuditPage{}, err
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultAuditPageSize
+	}
+
+	// лишняя запись показывает, что за страницей есть продолжение
+	filter.Limit = limit + 1
+	records, err := s.audit.List(ctx, filter)
+	if err != nil {
+		return AuditPage{}, err
+	}
+
+	page := AuditPage{Records: records}
+	if len(records) > limit {
+		page.Records = records[:limit]
+		page.NextBeforeID = page.Records[limit-1].ID
+	}
+	return page, nil
+}
+
+// auditReassignment пишет в журнал замену ревьювера с её причиной. Вызывается в транзакции замены.
+func (s *Service) auditReassignment(ctx context.Context, reason AssignmentReason, reassignment Reassignment) error {
+	if reason == AssignmentReasonSLA && ActorFromContext(ctx) == "" {
+		ctx = WithActor(ctx, SystemActorID)
+	}
+	payload := map[string]any{
+		"pull_request_id": reassignment.PullRequestID,
+		"old_reviewer_id": reassignment.OldReviewerID,
+		"reason":          reason,
+	}
+	result := map[string]any{"new_reviewer_id": reassignment.NewReviewerID, "fallback_team": reassignment.FallbackTeam}
+	return s.recordAudit(ctx, AuditOperationReassignReviewer, reassignment.PullRequestID, payload, result)
+}
+
+func auditMembers(members []User) []map[string]any {
+	result := make([]map[string]any, len(members))
+	for i, member := range members {
+		result[i] = map[string]any{"user_id": member.ID, "username": member.Username, "is_active": member.IsActive}
+	}
+	return result
+}
+
+func auditSettings(settings TeamSettings) map[string]any {
+	return map[string]any{
+		"reviewer_strategy":  settings.ReviewerStrategy,
+		"required_reviewers": settings.RequiredReviewers,
+		"required_approvals": settings.RequiredApprovals,
+		"fallback_teams":     settings.FallbackTeams,
+		"auto_reassign":      settings.AutoReassign,
+		"review_sla":         settings.ReviewSLA.String(),
+	}
+}
+
+func auditOwnershipRules(rules []OwnershipRule) []map[string]any {
+	result := make([]map[string]any, len(rules))
+	for i, rule := range rules {
+		result[i] = map[string]any{"pattern": rule.Pattern, "users": rule.Users, "teams": rule.Teams}
+	}
+	return result
+}
+
+func auditUnavailability(windows []Unavailability) []map[string]any {
+	result := make([]map[string]any, len(windows))
+	for i, window := range windows {
+		result[i] = map[string]any{"from": window.From.UTC(), "to": window.To.UTC()}
+	}
+	return result
+}
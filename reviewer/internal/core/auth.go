@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Role - роль владельца токена API.
+type Role string
+
+const (
+	// RoleAdmin управляет командами, пользователями и токенами и может выполнять любые операции.
+	RoleAdmin Role = "admin"
+	// RoleUser читает свои ревью и работает только с PR, где он автор или ревьювер.
+	RoleUser Role = "user"
+)
+
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleUser
+}
+
+// tokenPrefix отличает токены сервиса от других секретов, например в логах и сканерах утечек.
+const tokenPrefix = "prr_"
+
+// APIToken - токен доступа к API. Сам токен не хранится, только его хэш.
+type APIToken struct {
+	ID   int64
+	Name string
+	Role Role
+	// пользователь, от имени которого действует токен; обязателен для роли user
+	UserID    string
+	Hash      string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (t *APIToken) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidToken)
+	}
+	if !t.Role.IsValid() {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidToken, t.Role)
+	}
+	if t.Role == RoleUser && t.UserID == "" {
+		return fmt.Errorf("%w: user_id is required for role %s", ErrInvalidToken, RoleUser)
+	}
+	return nil
+}
+
+// IsRevoked сообщает, отозван ли токен.
+func (t *APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// HashToken возвращает SHA-256 токена в hex. Токены случайные и длинные, поэтому
+// медленный хэш паролей не нужен, а детерминированный позволяет искать токен по хэшу.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(secret), nil
+}
+
+// Principal - владелец токена, которым аутентифицирован запрос.
+type Principal struct {
+	TokenID   int64
+	TokenName string
+	Role      Role
+	UserID    string
+}
+
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет в контексте владельца токена запроса.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает владельца токена запроса. Его нет у вызовов самого
+// сервиса (SLA, интеграции) и при отключённой аутентификации - они не ограничиваются ролями.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// requireAdmin разрешает операцию только администратору.
+func requireAdmin(ctx context.Context) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return nil
+	}
+	return fmt.Errorf("%w: admin role is required", ErrForbidden)
+}
+
+// requireUser разрешает операцию администратору и самому пользователю userID.
+func requireUser(ctx context.Context, userID string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.IsAdmin() || principal.UserID == userID {
+		return nil
+	}
+	return fmt.Errorf("%w: operation is allowed only for user %s", ErrForbidden, userID)
+}
+
+// authorizePR разрешает операцию над PR администратору, автору и назначенным ревьюверам.
+func (s *Service) authorizePR(ctx context.Context, prID string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return nil
+	}
+
+	pr, err := s.prStore.GetByID(ctx, prID)
+	if err != nil {
+		return err
+	}
+	if pr.AuthorID != principal.UserID && !pr.HasReviewer(principal.UserID) {
+		return fmt.Errorf("%w: only the author and reviewers of PR %s can access it", ErrForbidden, prID)
+	}
+	return nil
+}
+
+// Authenticate возвращает владельца токена. Неизвестный и отозванный токены не различаются.
+func (s *Service) Authenticate(ctx context.Context, token string) (Principal, error) {
+	if s.tokens == nil {
+		return Principal{}, ErrAuthDisabled
+	}
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return Principal{}, ErrUnauthorized
+	}
+
+	stored, err := s.tokens.GetByHash(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Principal{}, ErrUnauthorized
+		}
+		return Principal{}, err
+	}
+	if stored.IsRevoked() {
+		return Principal{}, ErrUnauthorized
+	}
+	return Principal{TokenID: stored.ID, TokenName: stored.Name, Role: stored.Role, UserID: stored.UserID}, nil
+}
+
+// CreateToken выпускает токен и возвращает его вместе с описанием. Токен показывается
+// только здесь: сохраняется лишь его хэш.
+func (s *Service) CreateToken(ctx context.Context, name string, role Role, userID string) (*APIToken, string, error) {
+	if s.tokens == nil {
+		return nil, "", ErrAuthDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return nil, "", err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+	apiToken := &APIToken{Name: name, Role: role, UserID: userID, Hash: HashToken(token)}
+	if err := s.createToken(ctx, apiToken); err != nil {
+		return nil, "", err
+	}
+	return apiToken, token, nil
+}
+
+// EnsureToken регистрирует заранее известный токен, например токен администратора из
+// конфигурации. Уже зарегистрированный токен не меняется, даже если он отозван.
+func (s *Service) EnsureToken(ctx context.Context, name string, role Role, token string) error {
+	if s.tokens == nil {
+		return ErrAuthDisabled
+	}
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return fmt.Errorf("%w: token must start with %q", ErrInvalidToken, tokenPrefix)
+	}
+
+	hash := HashToken(token)
+	_, err := s.tokens.GetByHash(ctx, hash)
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.createToken(ctx, &APIToken{Name: name, Role: role, Hash: hash})
+}
+
+func (s *Service) createToken(ctx context.Context, token *APIToken) error {
+	if err := token.Validate(); err != nil {
+		return err
+	}
+	if token.UserID != "" {
+		if _, err := s.userStore.GetByID(ctx, token.UserID); err != nil {
+			return err
+		}
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokens.Create(ctx, token); err != nil {
+			return err
+		}
+		// сам токен и его хэш в журнал не попадают
+		payload := map[string]any{"name": token.Name, "role": token.Role, "user_id": token.UserID}
+		tokenID := strconv.FormatInt(token.ID, 10)
+		return s.recordAudit(ctx, AuditOperationCreateToken, tokenID, payload, map[string]any{"token_id": token.ID})
+	})
+}
+
+func (s *Service) ListTokens(ctx context.Context) ([]APIToken, error) {
+	if s.tokens == nil {
+		return nil, ErrAuthDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.tokens.List(ctx)
+}
+
+// RevokeToken отзывает токен. Повторный отзыв не меняет время первого.
+func (s *Service) RevokeToken(ctx context.Context, id int64) error {
+	if s.tokens == nil {
+		return ErrAuthDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokens.Revoke(ctx, id, time.Now()); err != nil {
+			return err
+		}
+		tokenID := strconv.FormatInt(id, 10)
+		return s.recordAudit(ctx, AuditOperationRevokeToken, tokenID, map[string]any{"token_id": id}, map[string]any{})
+	})
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// OwnershipRule - правило владения кодом в стиле CODEOWNERS: файлы, подходящие под Pattern,
+// принадлежат пользователям Users и активным участникам команд Teams.
+//
+// Pattern - glob относительно корня репозитория: `*` и `?` не выходят за пределы одного
+// сегмента пути, `**` совпадает с любым числом каталогов. Шаблон без `/` совпадает с именем
+// файла в любом каталоге, шаблон с `/` на конце - со всем содержимым каталога.
+type OwnershipRule struct {
+	Pattern string
+	Users   []string
+	Teams   []string
+}
+
+func (r OwnershipRule) Validate() error {
+	if strings.Trim(r.Pattern, "/") == "" {
+		return fmt.Errorf("%w: pattern is empty", ErrInvalidCodeOwners)
+	}
+	for _, segment := range strings.Split(r.Pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("%w: bad pattern %q", ErrInvalidCodeOwners, r.Pattern)
+		}
+	}
+	if len(r.Users) == 0 && len(r.Teams) == 0 {
+		return fmt.Errorf("%w: pattern %q has no owners", ErrInvalidCodeOwners, r.Pattern)
+	}
+	return nil
+}
+
+// Matches сообщает, подходит ли путь файла под шаблон правила.
+func (r OwnershipRule) Matches(filePath string) bool {
+	pattern := r.Pattern
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(strings.TrimSuffix(pattern, "/**"), "/") {
+		pattern = "**/" + pattern
+	}
+
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(filePath, "/"), "/")
+	return matchSegments(patternSegments, pathSegments)
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		// `**` поглощает от нуля сегментов до всех оставшихся
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	matched, err := path.Match(pattern[0], segments[0])
+	if err != nil || !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// matchOwnershipRules возвращает правила, которые определяют владельцев изменённых файлов.
+// Как в CODEOWNERS, для каждого файла действует последнее подходящее правило.
+func matchOwnershipRules(rules []OwnershipRule, changedFiles []string) []OwnershipRule {
+	matched := make([]OwnershipRule, 0)
+	seen := make(map[int]bool)
+	for _, filePath := range changedFiles {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !rules[i].Matches(filePath) {
+				continue
+			}
+			if !seen[i] {
+				seen[i] = true
+				matched = append(matched, rules[i])
+			}
+			break
+		}
+	}
+	return matched
+}
@@ -0,0 +1,55 @@
+package core_test
+
+import (
+	"testing"
+
+	"pr-reviewer/internal/core"
+)
+
+func TestOwnershipRule_Matches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/core/service.go", true},
+		{"*.go", "README.md", false},
+		{"docs/", "docs/api/openapi.yml", true},
+		{"docs/", "internal/docs/readme.md", true},
+		{"/docs/", "internal/docs/readme.md", false},
+		{"internal/core/*", "internal/core/models.go", true},
+		{"internal/core/*", "internal/core/sub/models.go", false},
+		{"internal/**/db/*.sql", "internal/db/001.sql", true},
+		{"internal/**/db/*.sql", "internal/adapters/db/001.sql", true},
+		{"internal/**/db/*.sql", "internal/adapters/db/pr.go", false},
+		{"**/migrations/**", "reviewer/internal/adapters/db/migrations/001.up.sql", true},
+		{"/Makefile", "Makefile", true},
+		{"/Makefile", "reviewer/Makefile", false},
+	}
+
+	for _, tt := range tests {
+		rule := core.OwnershipRule{Pattern: tt.pattern, Users: []string{"u1"}}
+		if got := rule.Matches(tt.path); got != tt.want {
+			t.Errorf("pattern %q, path %q: expected %v, got %v", tt.pattern, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestOwnershipRule_Validate(t *testing.T) {
+	invalid := []core.OwnershipRule{
+		{Pattern: "", Users: []string{"u1"}},
+		{Pattern: "internal/[core", Users: []string{"u1"}},
+		{Pattern: "*.go"},
+	}
+	for _, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("expected error for rule %+v", rule)
+		}
+	}
+
+	valid := core.OwnershipRule{Pattern: "internal/**", Teams: []string{"backend"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid rule, got %v", err)
+	}
+}
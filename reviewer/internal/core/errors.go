@@ -3,10 +3,38 @@ package core
 import "errors"
 
 var (
-	ErrTeamExists  = errors.New("team already exists")
-	ErrPRExists    = errors.New("PR already exists")
-	ErrPRMerged    = errors.New("cannot modify merged PR")
-	ErrNotAssigned = errors.New("reviewer is not assigned")
-	ErrNoCandidate = errors.New("no active candidate available")
-	ErrNotFound    = errors.New("resource not found")
+	ErrTeamExists   = errors.New("team already exists")
+	ErrPRExists     = errors.New("PR already exists")
+	ErrPRMerged     = errors.New("cannot modify merged PR")
+	ErrPRClosed     = errors.New("cannot modify closed PR")
+	ErrNotAssigned  = errors.New("reviewer is not assigned")
+	ErrNoCandidate  = errors.New("no active candidate available")
+	ErrNotFound     = errors.New("resource not found")
+	ErrMergeBlocked = errors.New("merge blocked by team policy")
+
+	ErrInvalidSettings       = errors.New("invalid team settings")
+	ErrInvalidReviewState    = errors.New("invalid review state")
+	ErrInvalidOverride       = errors.New("invalid merge override")
+	ErrInvalidCodeOwners     = errors.New("invalid code owners rule")
+	ErrInvalidUnavailability = errors.New("invalid unavailability window")
+	ErrInvalidWebhook        = errors.New("invalid webhook subscription")
+	ErrWebhooksDisabled      = errors.New("webhooks are disabled")
+	ErrHistoryDisabled       = errors.New("assignment history is disabled")
+	ErrInvalidFilter         = errors.New("invalid PR filter")
+
+	ErrInvalidAccount       = errors.New("invalid external account")
+	ErrUnknownAccount       = errors.New("external account is not linked")
+	ErrIntegrationsDisabled = errors.New("integrations are disabled")
+
+	ErrUnauthorized = errors.New("missing or invalid API token")
+	ErrForbidden    = errors.New("operation is not allowed")
+	ErrInvalidToken = errors.New("invalid API token")
+	ErrAuthDisabled = errors.New("authentication is disabled")
+
+	ErrAuditDisabled = errors.New("audit log is disabled")
+
+	ErrIdempotencyKeyExists  = errors.New("idempotency key already exists")
+	ErrIdempotencyConflict   = errors.New("idempotency key is already used with a different request")
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
 )
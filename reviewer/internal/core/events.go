@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventPRCreated            EventType = "pr.created"
+	EventPRReviewerReassigned EventType = "pr.reviewer_reassigned"
+	EventPRMerged             EventType = "pr.merged"
+	EventPRClosed             EventType = "pr.closed"
+	EventPRReopened           EventType = "pr.reopened"
+	EventPRReviewSubmitted    EventType = "pr.review_submitted"
+	EventPRReviewOverdue      EventType = "pr.review_overdue"
+)
+
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventPRCreated, EventPRReviewerReassigned, EventPRMerged, EventPRClosed, EventPRReopened,
+		EventPRReviewSubmitted, EventPRReviewOverdue:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event - запись outbox о событии жизненного цикла PR.
+type Event struct {
+	ID           int64
+	Type         EventType
+	PullRequest  PullRequest
+	Reassignment *Reassignment
+	Escalation   *Escalation
+	OccurredAt   time.Time
+}
+
+func newPREvent(eventType EventType, pr *PullRequest) Event {
+	snapshot := *pr
+	snapshot.ReviewersIDs = append([]string{}, pr.ReviewersIDs...)
+	snapshot.Reviews = append([]Review{}, pr.Reviews...)
+
+	return Event{
+		Type:        eventType,
+		PullRequest: snapshot,
+		OccurredAt:  time.Now(),
+	}
+}
+
+func newReassignEvent(pr *PullRequest, reassignment Reassignment) Event {
+	event := newPREvent(EventPRReviewerReassigned, pr)
+	event.Reassignment = &reassignment
+	return event
+}
+
+type WebhookSubscription struct {
+	ID     int64
+	URL    string
+	Secret string
+	// пустой список означает подписку на все события
+	EventTypes []EventType
+	CreatedAt  time.Time
+}
+
+func (s *WebhookSubscription) Validate() error {
+	parsed, err := url.Parse(s.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("%w: secret is required", ErrInvalidWebhook)
+	}
+	for _, eventType := range s.EventTypes {
+		if !eventType.IsValid() {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+	return nil
+}
+
+func (s *WebhookSubscription) Wants(eventType EventType) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, wanted := range s.EventTypes {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery - попытка доставить событие одному подписчику.
+type WebhookDelivery struct {
+	ID           int64
+	Event        Event
+	Subscription WebhookSubscription
+	Attempts     int
+}
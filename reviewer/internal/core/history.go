@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// AssignmentReason - почему ревьювер был назначен на PR.
+type AssignmentReason string
+
+const (
+	// AssignmentReasonInitial - назначение при создании PR.
+	AssignmentReasonInitial AssignmentReason = "initial"
+	// AssignmentReasonReassign - ручное переназначение через /pullRequest/reassign.
+	AssignmentReasonReassign AssignmentReason = "reassign"
+	// AssignmentReasonDeactivation - замена деактивированного ревьювера.
+	AssignmentReasonDeactivation AssignmentReason = "deactivation"
+	// AssignmentReasonReopen - замена недоступного ревьювера при повторном открытии PR.
+	AssignmentReasonReopen AssignmentReason = "reopen"
+	// AssignmentReasonSLA - замена ревьювера, просрочившего SLA команды.
+	AssignmentReasonSLA AssignmentReason = "sla"
+)
+
+// Assignment - запись истории назначений: ревьювер был назначен на PR в AssignedAt и снят
+// в UnassignedAt, nil означает, что он назначен до сих пор.
+type Assignment struct {
+	ID            int64
+	PullRequestID string
+	ReviewerID    string
+	AssignedAt    time.Time
+	UnassignedAt  *time.Time
+	Reason        AssignmentReason
+	// ревьювер, которого заменил этот, пусто при первоначальном назначении
+	ReplacedReviewerID string
+	// кто выполнил действие, пусто для действий самого сервиса
+	ActorID string
+}
+
+type actorKey struct{}
+
+// WithActor сохраняет в контексте пользователя, от имени которого выполняется операция.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// ActorFromContext возвращает пользователя операции или пустую строку, если он не задан.
+func ActorFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
+
+// initialAssignments описывает назначение ревьюверов нового PR.
+func initialAssignments(ctx context.Context, pr *PullRequest) []Assignment {
+	actorID := ActorFromContext(ctx)
+	assignments := make([]Assignment, len(pr.ReviewersIDs))
+	for i, reviewerID := range pr.ReviewersIDs {
+		assignments[i] = Assignment{
+			PullRequestID: pr.ID,
+			ReviewerID:    reviewerID,
+			AssignedAt:    pr.Review(reviewerID).AssignedAt,
+			Reason:        AssignmentReasonInitial,
+			ActorID:       actorID,
+		}
+	}
+	return assignments
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxIdempotencyKeyLength - ограничение длины ключа, как у колонки idempotency_key.
+const MaxIdempotencyKeyLength = 255
+
+// IdempotentResponse - сохранённый ответ на запрос с ключом идемпотентности.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// IdempotencyRecord - ключ идемпотентности. Response пуст, пока первый запрос с ключом выполняется.
+// Пока ответа нет, ExpiresAt - конец аренды ключа: ключ запроса, который упал или завис,
+// освобождается через аренду, а не через TTL ответа.
+type IdempotencyRecord struct {
+	// владелец ключа: ключи разных токенов не пересекаются
+	Scope string
+	Key   string
+	// SHA-256 маршрута и тела запроса; повтор с тем же ключом должен совпадать
+	Fingerprint string
+	Response    *IdempotentResponse
+	// начало первого запроса с ключом
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// idempotencyScope - владелец ключей запроса. Без токена ключи общие.
+func idempotencyScope(ctx context.Context) string {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return "token:" + strconv.FormatInt(principal.TokenID, 10)
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("%w: key must be 1 to %d characters long", ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+	for _, c := range key {
+		if c < 0x21 || c > 0x7e {
+			return fmt.Errorf("%w: key must contain only printable ASCII characters", ErrInvalidIdempotencyKey)
+		}
+	}
+	return nil
+}
+
+func requestFingerprint(route string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(route))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// BeginIdempotentRequest резервирует ключ за запросом route с телом body. Если ключ уже
+// использован тем же запросом, возвращает сохранённый ответ, который нужно отдать вместо
+// повторного выполнения. Без хранилища ключи не учитываются.
+func (s *Service) BeginIdempotentRequest(ctx context.Context, key, route string, body []byte) (*IdempotentResponse, error) {
+	if s.idempotency == nil {
+		return nil, nil
+	}
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &IdempotencyRecord{
+		Scope:       idempotencyScope(ctx),
+		Key:         key,
+		Fingerprint: requestFingerprint(route, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.idempotencyLease),
+	}
+	err := s.idempotency.Create(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, ErrIdempotencyKeyExists) {
+		return nil, err
+	}
+
+	existing, err := s.idempotency.Get(ctx, record.Scope, key)
+	if err != nil {
+		// ключ освобождён между Create и Get: первый запрос завершился ошибкой
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrIdempotencyInProgress
+		}
+		return nil, err
+	}
+	if existing.Fingerprint != record.Fingerprint {
+		return nil, ErrIdempotencyConflict
+	}
+	if existing.Response == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return existing.Response, nil
+}
+
+// CompleteIdempotentRequest сохраняет ответ на запрос, для которого зарезервирован ключ,
+// и продлевает ключ с аренды до TTL ответа.
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, key string, response IdempotentResponse) error {
+	if s.idempotency == nil {
+		return nil
+	}
+	return s.idempotency.Complete(ctx, idempotencyScope(ctx), key, response, time.Now().Add(s.idempotencyTTL))
+}
+
+// AbortIdempotentRequest освобождает ключ запроса, который не удалось выполнить,
+// чтобы повтор с тем же ключом выполнился заново.
+func (s *Service) AbortIdempotentRequest(ctx context.Context, key string) error {
+	if s.idempotency == nil {
+		return nil
+	}
+	return s.idempotency.Delete(ctx, idempotencyScope(ctx), key)
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPRPageSize = 50
+	MaxPRPageSize     = 100
+)
+
+// PRFilter - условия выборки PR. Пустые поля не ограничивают выборку, интервалы
+// времени полуоткрытые: [From, To).
+type PRFilter struct {
+	Status     PullRequestStatus
+	AuthorID   string
+	ReviewerID string
+	// команда автора PR
+	TeamName string
+
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+
+	// 0 означает DefaultPRPageSize
+	Limit int
+	// PR после курсора, nil - с начала
+	After *PRCursor
+}
+
+func (f PRFilter) Validate() error {
+	if f.Status != "" && !f.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, f.Status)
+	}
+	if f.Limit < 0 || f.Limit > MaxPRPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxPRPageSize)
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return fmt.Errorf("%w: created_from must be before created_to", ErrInvalidFilter)
+	}
+	if f.MergedFrom != nil && f.MergedTo != nil && !f.MergedFrom.Before(*f.MergedTo) {
+		return fmt.Errorf("%w: merged_from must be before merged_to", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// PRCursor - позиция в списке PR, упорядоченном по времени создания и ID.
+type PRCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PRPage - страница списка PR. Next равен nil на последней странице.
+type PRPage struct {
+	PullRequests []*PullRequest
+	Next         *PRCursor
+}
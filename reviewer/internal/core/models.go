@@ -1,26 +1,221 @@
 package core
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type PullRequestStatus string
 
 const (
 	PullRequestStatusOpen   PullRequestStatus = "OPEN"
 	PullRequestStatusMerged PullRequestStatus = "MERGED"
+	PullRequestStatusClosed PullRequestStatus = "CLOSED"
 )
 
+func (s PullRequestStatus) IsValid() bool {
+	switch s {
+	case PullRequestStatusOpen, PullRequestStatusMerged, PullRequestStatusClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+const DefaultRequiredReviewers = 2
+
 type User struct {
 	ID       string
 	Username string
 	TeamName string
 	IsActive bool
+	// периоды отсутствия, например отпуск
+	Unavailability []Unavailability
 }
 
+// CanBeReviewer сообщает, можно ли назначить пользователя на ревью сейчас.
 func (u *User) CanBeReviewer() bool {
-	return u.IsActive
+	return u.IsActive && !u.IsUnavailableAt(time.Now())
+}
+
+func (u *User) IsUnavailableAt(at time.Time) bool {
+	for _, window := range u.Unavailability {
+		if window.Covers(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unavailability - период [From, To), в который пользователь не назначается на ревью.
+type Unavailability struct {
+	From time.Time
+	To   time.Time
+}
+
+func (u Unavailability) Validate() error {
+	if u.From.IsZero() || u.To.IsZero() {
+		return fmt.Errorf("%w: from and to are required", ErrInvalidUnavailability)
+	}
+	if !u.To.After(u.From) {
+		return fmt.Errorf("%w: to must be after from", ErrInvalidUnavailability)
+	}
+	return nil
+}
+
+func (u Unavailability) Covers(at time.Time) bool {
+	return !at.Before(u.From) && at.Before(u.To)
 }
 
 type Team struct {
-	Name    string
-	Members []User
+	Name     string
+	Members  []User
+	Settings TeamSettings
+	// правила владения кодом, применяются к PR авторов из команды
+	OwnershipRules []OwnershipRule
+}
+
+type TeamSettings struct {
+	// пустая стратегия означает стратегию из конфига
+	ReviewerStrategy  SelectionStrategy
+	RequiredReviewers int
+	// сколько одобрений нужно для merge, 0 отключает проверку
+	RequiredApprovals int
+	// запасные команды по порядку, из которых добираются ревьюверы,
+	// если в собственной команде не хватает кандидатов
+	FallbackTeams []string
+	// при деактивации участника заменять его во всех открытых PR
+	AutoReassign bool
+	// сколько ревью может ждать в PENDING, 0 отключает контроль
+	ReviewSLA time.Duration
+}
+
+// WithDefaults заполняет незаданные настройки значениями по умолчанию.
+func (s TeamSettings) WithDefaults() TeamSettings {
+	if s.RequiredReviewers == 0 {
+		s.RequiredReviewers = DefaultRequiredReviewers
+	}
+	return s
+}
+
+func (s TeamSettings) Validate() error {
+	if s.ReviewerStrategy != "" && !s.ReviewerStrategy.IsValid() {
+		return fmt.Errorf("%w: unknown reviewer strategy %q", ErrInvalidSettings, s.ReviewerStrategy)
+	}
+	if s.RequiredReviewers < 1 {
+		return fmt.Errorf("%w: required reviewers must be positive", ErrInvalidSettings)
+	}
+	if s.RequiredApprovals < 0 {
+		return fmt.Errorf("%w: required approvals must not be negative", ErrInvalidSettings)
+	}
+	if s.ReviewSLA < 0 {
+		return fmt.Errorf("%w: review SLA must not be negative", ErrInvalidSettings)
+	}
+
+	seen := make(map[string]bool, len(s.FallbackTeams))
+	for _, fallbackTeam := range s.FallbackTeams {
+		if fallbackTeam == "" {
+			return fmt.Errorf("%w: fallback team name is empty", ErrInvalidSettings)
+		}
+		if seen[fallbackTeam] {
+			return fmt.Errorf("%w: duplicate fallback team %q", ErrInvalidSettings, fallbackTeam)
+		}
+		seen[fallbackTeam] = true
+	}
+	return nil
+}
+
+// CheckMergePolicy проверяет, что PR набрал нужное число одобрений назначенных ревьюверов
+// и ни один из них не запросил изменения. Без RequiredApprovals проверка отключена.
+func (s TeamSettings) CheckMergePolicy(pr *PullRequest) error {
+	if s.RequiredApprovals == 0 {
+		return nil
+	}
+
+	approvals := 0
+	var changesRequested []string
+	for _, reviewerID := range pr.ReviewersIDs {
+		switch pr.Review(reviewerID).State {
+		case ReviewStateApproved:
+			approvals++
+		case ReviewStateChangesRequested:
+			changesRequested = append(changesRequested, reviewerID)
+		}
+	}
+
+	if len(changesRequested) > 0 {
+		return fmt.Errorf("%w: changes requested by %s", ErrMergeBlocked, strings.Join(changesRequested, ", "))
+	}
+	if approvals < s.RequiredApprovals {
+		return fmt.Errorf("%w: %d of %d required approvals", ErrMergeBlocked, approvals, s.RequiredApprovals)
+	}
+	return nil
+}
+
+// TeamSettingsPatch описывает частичное обновление настроек, nil-поля не меняются.
+type TeamSettingsPatch struct {
+	ReviewerStrategy  *SelectionStrategy
+	RequiredReviewers *int
+	RequiredApprovals *int
+	FallbackTeams     *[]string
+	AutoReassign      *bool
+	ReviewSLA         *time.Duration
+}
+
+func (p TeamSettingsPatch) Apply(settings TeamSettings) TeamSettings {
+	if p.ReviewerStrategy != nil {
+		settings.ReviewerStrategy = *p.ReviewerStrategy
+	}
+	if p.RequiredReviewers != nil {
+		settings.RequiredReviewers = *p.RequiredReviewers
+	}
+	if p.RequiredApprovals != nil {
+		settings.RequiredApprovals = *p.RequiredApprovals
+	}
+	if p.FallbackTeams != nil {
+		settings.FallbackTeams = *p.FallbackTeams
+	}
+	if p.AutoReassign != nil {
+		settings.AutoReassign = *p.AutoReassign
+	}
+	if p.ReviewSLA != nil {
+		settings.ReviewSLA = *p.ReviewSLA
+	}
+	return settings
+}
+
+type ReviewState string
+
+const (
+	ReviewStatePending          ReviewState = "PENDING"
+	ReviewStateApproved         ReviewState = "APPROVED"
+	ReviewStateChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewStateDismissed        ReviewState = "DISMISSED"
+)
+
+// IsVerdict сообщает, может ли ревьювер выставить это состояние.
+func (s ReviewState) IsVerdict() bool {
+	switch s {
+	case ReviewStateApproved, ReviewStateChangesRequested, ReviewStateDismissed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Review - состояние ревью одного назначенного ревьювера.
+type Review struct {
+	ReviewerID string
+	State      ReviewState
+	AssignedAt time.Time
+	UpdatedAt  time.Time
+	// запасная команда, из которой назначен ревьювер, пустая для своей команды
+	FallbackTeam string
+	// шаблон правила владения кодом, по которому назначен ревьювер
+	OwnerPattern string
+	// когда просроченное ревью было эскалировано
+	EscalatedAt *time.Time
 }
 
 type PullRequest struct {
@@ -29,6 +224,116 @@ type PullRequest struct {
 	Status       PullRequestStatus
 	AuthorID     string
 	ReviewersIDs []string
+	// состояния ревью назначенных ревьюверов, отсутствующее означает PENDING
+	Reviews []Review
+	// сколько ревьюверов требовала команда автора на момент создания
+	RequiredReviewers int
+	// заполняется, если PR смержен в обход политики команды
+	MergeOverride *MergeOverride
+	// время создания, заполняется хранилищем, если не задано
+	CreatedAt time.Time
+	// время первого merge, не меняется при последующих обновлениях PR
+	MergedAt *time.Time
+}
+
+// MergeOverride - кто и почему смержил PR, не прошедший проверку политики merge.
+type MergeOverride struct {
+	ActorID string
+	Reason  string
+}
+
+// SetUserActiveOptions - Reassign при деактивации заменяет пользователя во всех открытых PR,
+// даже если у его команды не включён AutoReassign.
+type SetUserActiveOptions struct {
+	Reassign bool
+}
+
+// CreatePROptions - необязательные данные PR при создании.
+type CreatePROptions struct {
+	// пути изменённых файлов, по ним назначается владелец кода
+	ChangedFiles []string
+}
+
+// MergeOptions задаёт принудительный merge: Force пропускает проверку политики, Reason обязателен
+// и сохраняется в PR, если проверка не пройдена. Автор обхода берётся из контекста (WithActor,
+// пользователь токена); ActorID должен с ним совпадать и нужен только вызовам без пользователя.
+type MergeOptions struct {
+	Force   bool
+	ActorID string
+	Reason  string
+}
+
+// assignReviewers назначает ревьюверов с ревью в состоянии PENDING.
+// У переданных ревью используются только ReviewerID, FallbackTeam и OwnerPattern.
+func (pr *PullRequest) assignReviewers(reviews []Review, at time.Time) {
+	pr.ReviewersIDs = make([]string, len(reviews))
+	pr.Reviews = make([]Review, len(reviews))
+	for i, review := range reviews {
+		pr.ReviewersIDs[i] = review.ReviewerID
+		pr.Reviews[i] = Review{
+			ReviewerID:   review.ReviewerID,
+			State:        ReviewStatePending,
+			AssignedAt:   at,
+			UpdatedAt:    at,
+			FallbackTeam: review.FallbackTeam,
+			OwnerPattern: review.OwnerPattern,
+		}
+	}
+}
+
+// Review возвращает ревью назначенного ревьювера.
+func (pr *PullRequest) Review(reviewerID string) Review {
+	for _, review := range pr.Reviews {
+		if review.ReviewerID == reviewerID {
+			return review
+		}
+	}
+	return Review{ReviewerID: reviewerID, State: ReviewStatePending}
+}
+
+func (pr *PullRequest) setReviewState(reviewerID string, state ReviewState, at time.Time) {
+	for i := range pr.Reviews {
+		if pr.Reviews[i].ReviewerID == reviewerID {
+			pr.Reviews[i].State = state
+			pr.Reviews[i].UpdatedAt = at
+			return
+		}
+	}
+	pr.Reviews = append(pr.Reviews, Review{ReviewerID: reviewerID, State: state, AssignedAt: at, UpdatedAt: at})
+}
+
+// OverdueReviews возвращает ревью в состоянии PENDING, назначенные не позже now - sla.
+// У не открытого PR и при нулевом SLA просроченных ревью нет.
+func (pr *PullRequest) OverdueReviews(sla time.Duration, now time.Time) []Review {
+	if sla <= 0 || !pr.CanReassign() {
+		return nil
+	}
+
+	var overdue []Review
+	for _, reviewerID := range pr.ReviewersIDs {
+		review := pr.Review(reviewerID)
+		if review.State == ReviewStatePending && !review.AssignedAt.Add(sla).After(now) {
+			overdue = append(overdue, review)
+		}
+	}
+	return overdue
+}
+
+func (pr *PullRequest) markEscalated(reviewerID string, at time.Time) {
+	for i := range pr.Reviews {
+		if pr.Reviews[i].ReviewerID == reviewerID {
+			pr.Reviews[i].EscalatedAt = &at
+			return
+		}
+	}
+}
+
+// MissingReviewers возвращает, скольких ревьюверов не хватает до требования команды.
+func (pr *PullRequest) MissingReviewers() int {
+	if missing := pr.RequiredReviewers - len(pr.ReviewersIDs); missing > 0 {
+		return missing
+	}
+	return 0
 }
 
 func (pr *PullRequest) CanReassign() bool {
@@ -39,6 +344,55 @@ func (pr *PullRequest) IsMerged() bool {
 	return pr.Status == PullRequestStatusMerged
 }
 
+func (pr *PullRequest) IsClosed() bool {
+	return pr.Status == PullRequestStatusClosed
+}
+
+// checkModifiable возвращает ошибку, если PR уже не открыт.
+func (pr *PullRequest) checkModifiable() error {
+	switch {
+	case pr.IsMerged():
+		return ErrPRMerged
+	case pr.IsClosed():
+		return ErrPRClosed
+	default:
+		return nil
+	}
+}
+
+// replaceReviewer заменяет ревьювера oldID на newID, пустой newID снимает ревьювера.
+// Ревью снятого ревьювера удаляется, новый получает ревью в состоянии PENDING.
+func (pr *PullRequest) replaceReviewer(oldID, newID, fallbackTeam string) {
+	reviewerIDs := make([]string, 0, len(pr.ReviewersIDs))
+	for _, reviewerID := range pr.ReviewersIDs {
+		switch {
+		case reviewerID != oldID:
+			reviewerIDs = append(reviewerIDs, reviewerID)
+		case newID != "":
+			reviewerIDs = append(reviewerIDs, newID)
+		}
+	}
+	pr.ReviewersIDs = reviewerIDs
+
+	reviews := make([]Review, 0, len(pr.Reviews))
+	for _, review := range pr.Reviews {
+		if review.ReviewerID != oldID {
+			reviews = append(reviews, review)
+		}
+	}
+	pr.Reviews = reviews
+	if newID != "" {
+		now := time.Now()
+		pr.Reviews = append(pr.Reviews, Review{
+			ReviewerID:   newID,
+			State:        ReviewStatePending,
+			AssignedAt:   now,
+			UpdatedAt:    now,
+			FallbackTeam: fallbackTeam,
+		})
+	}
+}
+
 func (pr *PullRequest) HasReviewer(userID string) bool {
 	for _, reviewerID := range pr.ReviewersIDs {
 		if reviewerID == userID {
@@ -47,3 +401,15 @@ func (pr *PullRequest) HasReviewer(userID string) bool {
 	}
 	return false
 }
+
+// Reassignment описывает замену ревьювера в PR. Пустой NewReviewerID означает,
+// что замены не нашлось и ревьювер снят.
+type Reassignment struct {
+	PullRequestID string
+	OldReviewerID string
+	NewReviewerID string
+	// запасная команда нового ревьювера, пустая для своей команды
+	FallbackTeam string
+	// причина, по которой замена не удалась; ревьювер при этом остаётся назначен
+	Err error
+}
@@ -1,16 +1,27 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type TeamStore interface {
 	Create(ctx context.Context, team *Team) error
 	GetByName(ctx context.Context, name string) (*Team, error)
+	UpdateSettings(ctx context.Context, name string, settings TeamSettings) error
+	// UpdateOwnershipRules заменяет правила владения кодом команды целиком.
+	UpdateOwnershipRules(ctx context.Context, name string, rules []OwnershipRule) error
 }
 
 type UserStore interface {
 	GetByID(ctx context.Context, id string) (*User, error)
 	Update(ctx context.Context, user *User) error
+	// GetActiveByTeamName возвращает активных участников команды, которые сейчас не в отсутствии.
 	GetActiveByTeamName(ctx context.Context, teamName string) ([]*User, error)
+	// SetActiveByTeam меняет флаг активности участников команды и возвращает обновлённых.
+	SetActiveByTeam(ctx context.Context, teamName string, userIDs []string, isActive bool) ([]*User, error)
+	// SetUnavailability заменяет периоды отсутствия пользователя целиком.
+	SetUnavailability(ctx context.Context, userID string, windows []Unavailability) error
 }
 
 type PRStore interface {
@@ -18,5 +29,113 @@ type PRStore interface {
 	GetByID(ctx context.Context, id string) (*PullRequest, error)
 	Update(ctx context.Context, pr *PullRequest) error
 	GetByReviewerID(ctx context.Context, userID string) ([]*PullRequest, error)
-	GetStatistics(ctx context.Context) (map[string]int, error)
+	// List возвращает не больше filter.Limit PR, подходящих под фильтр, в порядке PRCursor.
+	List(ctx context.Context, filter PRFilter) ([]*PullRequest, error)
+	GetOpenByReviewerIDs(ctx context.Context, userIDs []string) ([]*PullRequest, error)
+	// GetOverdue возвращает OPEN PR, где есть PENDING ревью старше review SLA команды автора на момент now.
+	GetOverdue(ctx context.Context, now time.Time) ([]*PullRequest, error)
+	// GetStatistics считает статистику по PR, авторы которых входят в filter.TeamName (любые, если пусто).
+	GetStatistics(ctx context.Context, filter StatisticsFilter) (*Statistics, error)
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+}
+
+type WorkloadCounter interface {
+	CountOpenReviews(ctx context.Context, userIDs []string) (map[string]int, error)
+}
+
+// ReviewerSelector выбирает до count ревьюверов из кандидатов команды.
+type ReviewerSelector interface {
+	Select(ctx context.Context, team *Team, candidates []*User, count int) ([]string, error)
+}
+
+// Transactor выполняет fn в одной транзакции хранилища. Сторы, вызванные
+// с переданным в fn контекстом, работают внутри неё.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// EventStore - outbox: события пишутся в той же транзакции, что и изменения PR.
+type EventStore interface {
+	Append(ctx context.Context, event Event) error
+}
+
+type WebhookStore interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	// ScheduleDeliveries создаёт доставки для ещё не разосланных событий outbox
+	// по всем подходящим подпискам и возвращает число обработанных событий.
+	ScheduleDeliveries(ctx context.Context, limit int) (int, error)
+	// ClaimDueDeliveries захватывает доставки, время которых пришло, откладывая
+	// их следующую попытку на lease, чтобы их не взял другой экземпляр сервиса.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, attempts int, deliveredAt time.Time) error
+	// MarkFailed фиксирует неудачную попытку; nil nextAttemptAt означает, что попытки закончились.
+	MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt *time.Time, lastError string) error
+}
+
+// AssignmentStore - история назначений ревьюверов. Записи только добавляются,
+// при снятии ревьювера в его открытой записи проставляется время снятия.
+type AssignmentStore interface {
+	Append(ctx context.Context, assignments ...Assignment) error
+	// Close закрывает открытое назначение ревьювера на PR, если оно есть.
+	Close(ctx context.Context, prID, reviewerID string, at time.Time) error
+	GetByPullRequestID(ctx context.Context, prID string) ([]Assignment, error)
+}
+
+// ExternalAccountStore - соответствие логинов внешних систем пользователям сервиса.
+type ExternalAccountStore interface {
+	// Link создаёт или перезаписывает связь (provider, login) -> user_id.
+	Link(ctx context.Context, account ExternalAccount) error
+	// ResolveUserID возвращает ErrNotFound, если логин не связан.
+	ResolveUserID(ctx context.Context, provider, login string) (string, error)
+	// LoginsByUserIDs возвращает логины пользователей; несвязанные в результат не попадают.
+	LoginsByUserIDs(ctx context.Context, provider string, userIDs []string) (map[string]string, error)
+}
+
+// TokenStore - токены API. Токены ищутся по хэшу, сами токены не хранятся.
+type TokenStore interface {
+	// Create заполняет ID и CreatedAt токена.
+	Create(ctx context.Context, token *APIToken) error
+	GetByHash(ctx context.Context, hash string) (*APIToken, error)
+	List(ctx context.Context) ([]APIToken, error)
+	// Revoke отзывает токен в at, если он ещё не отозван.
+	Revoke(ctx context.Context, id int64, at time.Time) error
+}
+
+// AuditStore - журнал аудита. Записи только добавляются.
+type AuditStore interface {
+	// Append заполняет ID записи.
+	Append(ctx context.Context, record *AuditRecord) error
+	// List возвращает не больше filter.Limit записей, подходящих под фильтр, от новых к старым.
+	List(ctx context.Context, filter AuditFilter) ([]AuditRecord, error)
+}
+
+// IdempotencyStore - ключи идемпотентности и ответы на запросы с ними.
+type IdempotencyStore interface {
+	// Create сохраняет ключ без ответа и удаляет просроченные к record.CreatedAt ключи. Если у владельца
+	// уже есть действующий ключ с тем же значением, возвращает ErrIdempotencyKeyExists.
+	Create(ctx context.Context, record *IdempotencyRecord) error
+	Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error)
+	// Complete сохраняет ответ и новый срок хранения ключа.
+	Complete(ctx context.Context, scope, key string, response IdempotentResponse, expiresAt time.Time) error
+	Delete(ctx context.Context, scope, key string) error
+}
+
+// Metrics - доменные счётчики сервиса. Методы вызываются после успешного завершения
+// операции, поэтому откаченные транзакции не учитываются.
+type Metrics interface {
+	// PRCreated учитывает новый PR и число назначенных на него ревьюверов.
+	PRCreated(reviewers int)
+	PRMerged()
+	ReviewerReassigned(reason AssignmentReason)
+	// NoCandidate учитывает замену ревьювера, для которой не нашлось кандидата.
+	NoCandidate()
+}
+
+// Tracer - трассировка операций сервиса. Start начинает дочерний спан операции name
+// и возвращает функцию, которая завершает его с ошибкой операции (nil при успехе).
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, func(err error))
 }
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+type SelectionStrategy string
+
+const (
+	SelectionRandom      SelectionStrategy = "random"
+	SelectionRoundRobin  SelectionStrategy = "round_robin"
+	SelectionLeastLoaded SelectionStrategy = "least_loaded"
+	SelectionWeighted    SelectionStrategy = "weighted"
+)
+
+func (s SelectionStrategy) IsValid() bool {
+	switch s {
+	case SelectionRandom, SelectionRoundRobin, SelectionLeastLoaded, SelectionWeighted:
+		return true
+	default:
+		return false
+	}
+}
+
+// RandomSelector выбирает кандидатов случайно.
+type RandomSelector struct{}
+
+func (RandomSelector) Select(_ context.Context, _ *Team, candidates []*User, count int) ([]string, error) {
+	return firstIDs(shuffled(candidates), count), nil
+}
+
+// RoundRobinSelector назначает кандидатов команды по кругу в порядке user_id.
+// Позиция хранится в памяти процесса и сбрасывается при рестарте.
+type RoundRobinSelector struct {
+	mu   sync.Mutex
+	last map[string]string
+}
+
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{last: make(map[string]string)}
+}
+
+func (s *RoundRobinSelector) Select(_ context.Context, team *Team, candidates []*User, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	ordered := make([]*User, len(candidates))
+	copy(ordered, candidates)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// начинаем с первого кандидата после последнего назначенного
+	last := s.last[team.Name]
+	start := sort.Search(len(ordered), func(i int) bool {
+		return ordered[i].ID > last
+	})
+	if start == len(ordered) {
+		start = 0
+	}
+
+	rotated := make([]*User, 0, len(ordered))
+	rotated = append(rotated, ordered[start:]...)
+	rotated = append(rotated, ordered[:start]...)
+	result := firstIDs(rotated, count)
+	s.last[team.Name] = result[len(result)-1]
+
+	return result, nil
+}
+
+// LeastLoadedSelector выбирает кандидатов с наименьшим числом открытых ревью,
+// при равной загрузке порядок случайный.
+type LeastLoadedSelector struct {
+	workload WorkloadCounter
+}
+
+func NewLeastLoadedSelector(workload WorkloadCounter) *LeastLoadedSelector {
+	return &LeastLoadedSelector{workload: workload}
+}
+
+func (s *LeastLoadedSelector) Select(ctx context.Context, _ *Team, candidates []*User, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	loads, err := countLoads(ctx, s.workload, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := shuffled(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return loads[ordered[i].ID] < loads[ordered[j].ID]
+	})
+
+	return firstIDs(ordered, count), nil
+}
+
+// WeightedSelector выбирает кандидатов случайно с весом 1/(1+открытые ревью):
+// свободные участники назначаются чаще, но загруженные не исключаются полностью.
+type WeightedSelector struct {
+	workload WorkloadCounter
+}
+
+func NewWeightedSelector(workload WorkloadCounter) *WeightedSelector {
+	return &WeightedSelector{workload: workload}
+}
+
+func (s *WeightedSelector) Select(ctx context.Context, _ *Team, candidates []*User, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	loads, err := countLoads(ctx, s.workload, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := make([]*User, len(candidates))
+	copy(pool, candidates)
+	weights := make([]float64, len(pool))
+	for i, candidate := range pool {
+		weights[i] = 1 / float64(1+loads[candidate.ID])
+	}
+
+	result := make([]string, 0, count)
+	for len(result) < count && len(pool) > 0 {
+		total := 0.0
+		for _, weight := range weights {
+			total += weight
+		}
+
+		point := rand.Float64() * total
+		picked := len(pool) - 1
+		for i, weight := range weights {
+			if point < weight {
+				picked = i
+				break
+			}
+			point -= weight
+		}
+
+		result = append(result, pool[picked].ID)
+		pool = append(pool[:picked], pool[picked+1:]...)
+		weights = append(weights[:picked], weights[picked+1:]...)
+	}
+
+	return result, nil
+}
+
+// StrategySelector выбирает стратегию для команды: настройка команды из API,
+// затем настройка из конфига, затем стратегия по умолчанию.
+type StrategySelector struct {
+	selectors       map[SelectionStrategy]ReviewerSelector
+	defaultStrategy SelectionStrategy
+	teamStrategies  map[string]SelectionStrategy
+}
+
+func NewStrategySelector(
+	defaultStrategy SelectionStrategy,
+	teamStrategies map[string]SelectionStrategy,
+	workload WorkloadCounter,
+) (*StrategySelector, error) {
+	if !defaultStrategy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown reviewer strategy %q", ErrInvalidSettings, defaultStrategy)
+	}
+	for team, strategy := range teamStrategies {
+		if !strategy.IsValid() {
+			return nil, fmt.Errorf("%w: unknown reviewer strategy %q for team %s", ErrInvalidSettings, strategy, team)
+		}
+	}
+
+	return &StrategySelector{
+		selectors: map[SelectionStrategy]ReviewerSelector{
+			SelectionRandom:      RandomSelector{},
+			SelectionRoundRobin:  NewRoundRobinSelector(),
+			SelectionLeastLoaded: NewLeastLoadedSelector(workload),
+			SelectionWeighted:    NewWeightedSelector(workload),
+		},
+		defaultStrategy: defaultStrategy,
+		teamStrategies:  teamStrategies,
+	}, nil
+}
+
+func (s *StrategySelector) Select(ctx context.Context, team *Team, candidates []*User, count int) ([]string, error) {
+	return s.selectors[s.StrategyFor(team)].Select(ctx, team, candidates, count)
+}
+
+func (s *StrategySelector) StrategyFor(team *Team) SelectionStrategy {
+	if team.Settings.ReviewerStrategy != "" {
+		return team.Settings.ReviewerStrategy
+	}
+	if strategy, ok := s.teamStrategies[team.Name]; ok {
+		return strategy
+	}
+	return s.defaultStrategy
+}
+
+func countLoads(ctx context.Context, workload WorkloadCounter, candidates []*User) (map[string]int, error) {
+	candidateIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		candidateIDs[i] = candidate.ID
+	}
+	return workload.CountOpenReviews(ctx, candidateIDs)
+}
+
+func shuffled(candidates []*User) []*User {
+	result := make([]*User, len(candidates))
+	copy(result, candidates)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+func firstIDs(candidates []*User, count int) []string {
+	if len(candidates) < count {
+		count = len(candidates)
+	}
+
+	result := make([]string, count)
+	for i := 0; i < count; i++ {
+		result[i] = candidates[i].ID
+	}
+	return result
+}
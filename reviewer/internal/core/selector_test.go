@@ -0,0 +1,90 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"pr-reviewer/internal/core"
+)
+
+type staticWorkload map[string]int
+
+func (w staticWorkload) CountOpenReviews(_ context.Context, _ []string) (map[string]int, error) {
+	return w, nil
+}
+
+func users(ids ...string) []*core.User {
+	result := make([]*core.User, len(ids))
+	for i, id := range ids {
+		result[i] = &core.User{ID: id, Username: id, IsActive: true}
+	}
+	return result
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	selector := core.NewRoundRobinSelector()
+	team := &core.Team{Name: "backend"}
+	ctx := context.Background()
+
+	want := [][]string{{"u1", "u2"}, {"u3", "u1"}, {"u2", "u3"}}
+	for i, expected := range want {
+		got, err := selector.Select(ctx, team, users("u3", "u1", "u2"), 2)
+		if err != nil {
+			t.Fatalf("select failed: %v", err)
+		}
+		if len(got) != 2 || got[0] != expected[0] || got[1] != expected[1] {
+			t.Errorf("round %d: expected %v, got %v", i, expected, got)
+		}
+	}
+
+	// у другой команды своя позиция
+	got, _ := selector.Select(ctx, &core.Team{Name: "frontend"}, users("u1", "u2"), 1)
+	if got[0] != "u1" {
+		t.Errorf("expected frontend to start from u1, got %v", got)
+	}
+}
+
+func TestWeightedSelector_PrefersFreeCandidates(t *testing.T) {
+	selector := core.NewWeightedSelector(staticWorkload{"busy": 9})
+	ctx := context.Background()
+
+	picks := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		got, err := selector.Select(ctx, &core.Team{Name: "backend"}, users("busy", "free"), 1)
+		if err != nil {
+			t.Fatalf("select failed: %v", err)
+		}
+		picks[got[0]]++
+	}
+
+	if picks["free"] <= picks["busy"] || picks["busy"] == 0 {
+		t.Errorf("expected free to be picked more often but busy sometimes, got %v", picks)
+	}
+}
+
+func TestStrategySelector_Precedence(t *testing.T) {
+	selector, err := core.NewStrategySelector(core.SelectionLeastLoaded, map[string]core.SelectionStrategy{
+		"backend": core.SelectionRoundRobin,
+	}, staticWorkload{})
+	if err != nil {
+		t.Fatalf("failed to create selector: %v", err)
+	}
+
+	tests := []struct {
+		team     *core.Team
+		expected core.SelectionStrategy
+	}{
+		{&core.Team{Name: "frontend"}, core.SelectionLeastLoaded},
+		{&core.Team{Name: "backend"}, core.SelectionRoundRobin},
+		{&core.Team{Name: "backend", Settings: core.TeamSettings{ReviewerStrategy: core.SelectionWeighted}}, core.SelectionWeighted},
+	}
+	for _, tt := range tests {
+		if got := selector.StrategyFor(tt.team); got != tt.expected {
+			t.Errorf("team %s: expected %s, got %s", tt.team.Name, tt.expected, got)
+		}
+	}
+
+	if _, err := core.NewStrategySelector("fastest", nil, staticWorkload{}); err == nil {
+		t.Error("expected error for unknown default strategy")
+	}
+}
@@ -3,33 +3,201 @@ package core
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"fmt"
+	"sort"
+	"time"
 )
 
 type Service struct {
 	teamStore TeamStore
 	userStore UserStore
 	prStore   PRStore
+	selector  ReviewerSelector
+	tx        Transactor
+	events    EventStore
+	webhooks  WebhookStore
+	accounts  ExternalAccountStore
+	history   AssignmentStore
+	metrics   Metrics
+	tracer    Tracer
+	tokens    TokenStore
+	audit     AuditStore
+
+	idempotency    IdempotencyStore
+	idempotencyTTL time.Duration
+	// сколько ключ принадлежит запросу, который ещё не сохранил ответ
+	idempotencyLease time.Duration
+}
+
+type Option func(*Service)
+
+// WithTransactor задаёт транзакции хранилища. Без него операции выполняются без общей транзакции.
+func WithTransactor(tx Transactor) Option {
+	return func(s *Service) {
+		s.tx = tx
+	}
+}
+
+// WithEvents включает запись событий PR в outbox и управление подписками на них.
+func WithEvents(events EventStore, webhooks WebhookStore) Option {
+	return func(s *Service) {
+		s.events = events
+		s.webhooks = webhooks
+	}
+}
+
+// WithExternalAccounts включает сопоставление логинов внешних систем пользователям.
+func WithExternalAccounts(accounts ExternalAccountStore) Option {
+	return func(s *Service) {
+		s.accounts = accounts
+	}
+}
+
+// WithAssignmentHistory включает запись истории назначений ревьюверов.
+func WithAssignmentHistory(history AssignmentStore) Option {
+	return func(s *Service) {
+		s.history = history
+	}
+}
+
+// WithMetrics включает доменные счётчики.
+func WithMetrics(metrics Metrics) Option {
+	return func(s *Service) {
+		s.metrics = metrics
+	}
+}
+
+// WithTracer включает трассировку операций сервиса.
+func WithTracer(tracer Tracer) Option {
+	return func(s *Service) {
+		s.tracer = tracer
+	}
+}
+
+// WithTokens включает аутентификацию по токенам API и управление ими.
+func WithTokens(tokens TokenStore) Option {
+	return func(s *Service) {
+		s.tokens = tokens
+	}
+}
+
+// WithAudit включает журнал аудита изменяющих операций.
+func WithAudit(audit AuditStore) Option {
+	return func(s *Service) {
+		s.audit = audit
+	}
+}
+
+// WithIdempotency включает ключи идемпотентности: ответ на запрос с ключом хранится ttl,
+// а ключ запроса без ответа освобождается через lease.
+func WithIdempotency(store IdempotencyStore, ttl, lease time.Duration) Option {
+	return func(s *Service) {
+		s.idempotency = store
+		s.idempotencyTTL = ttl
+		s.idempotencyLease = lease
+	}
+}
+
+// WithReviewerSelector подменяет стратегию выбора ревьюверов.
+// По умолчанию используется least_loaded для всех команд.
+func WithReviewerSelector(selector ReviewerSelector) Option {
+	return func(s *Service) {
+		s.selector = selector
+	}
 }
 
-func NewService(teamStore TeamStore, userStore UserStore, prStore PRStore) *Service {
-	return &Service{
+func NewService(teamStore TeamStore, userStore UserStore, prStore PRStore, opts ...Option) *Service {
+	s := &Service{
 		teamStore: teamStore,
 		userStore: userStore,
 		prStore:   prStore,
+		selector:  NewLeastLoadedSelector(prStore),
+		tx:        noTx{},
+		events:    noEvents{},
+		metrics:   noMetrics{},
+		tracer:    noTracer{},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Service) CreateTeam(ctx context.Context, name string, members []User) error {
+	return s.CreateTeamWithSettings(ctx, name, members, TeamSettings{})
+}
+
+func (s *Service) CreateTeamWithSettings(ctx context.Context, name string, members []User, settings TeamSettings) (err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.CreateTeam")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	settings = settings.WithDefaults()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkFallbackTeams(ctx, name, settings.FallbackTeams); err != nil {
+		return err
+	}
+
 	existing, err := s.teamStore.GetByName(ctx, name)
 	if err == nil && existing != nil {
 		return ErrTeamExists
 	}
 
-	return s.teamStore.Create(ctx, &Team{Name: name, Members: members})
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.teamStore.Create(ctx, &Team{Name: name, Members: members, Settings: settings}); err != nil {
+			return err
+		}
+		payload := map[string]any{"team_name": name, "members": auditMembers(members), "settings": auditSettings(settings)}
+		return s.recordAudit(ctx, AuditOperationCreateTeam, name, payload, map[string]any{"members": len(members)})
+	})
+}
+
+func (s *Service) UpdateTeamSettings(ctx context.Context, name string, patch TeamSettingsPatch) (_ *Team, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.UpdateTeamSettings")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	team, err := s.teamStore.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := patch.Apply(team.Settings)
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkFallbackTeams(ctx, name, settings.FallbackTeams); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.teamStore.UpdateSettings(ctx, name, settings); err != nil {
+			return err
+		}
+		payload := map[string]any{"team_name": name, "settings": auditSettings(settings)}
+		result := map[string]any{"previous_settings": auditSettings(team.Settings)}
+		return s.recordAudit(ctx, AuditOperationUpdateTeamSettings, name, payload, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	team.Settings = settings
+	return team, nil
 }
 
 func (s *Service) GetTeam(ctx context.Context, name string) (*Team, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
 	team, err := s.teamStore.GetByName(ctx, name)
 	if err != nil {
 		return nil, err
@@ -37,21 +205,207 @@ func (s *Service) GetTeam(ctx context.Context, name string) (*Team, error) {
 	return team, nil
 }
 
-func (s *Service) SetUserActive(ctx context.Context, userID string, isActive bool) (*User, error) {
+// SetOwnershipRules заменяет правила владения кодом команды. Владельцы должны существовать.
+func (s *Service) SetOwnershipRules(ctx context.Context, teamName string, rules []OwnershipRule) (_ *Team, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.SetOwnershipRules")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	team, err := s.teamStore.GetByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		for _, userID := range rule.Users {
+			_, err := s.userStore.GetByID(ctx, userID)
+			if errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("%w: unknown user %q in pattern %q", ErrInvalidCodeOwners, userID, rule.Pattern)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, ownerTeam := range rule.Teams {
+			_, err := s.teamStore.GetByName(ctx, ownerTeam)
+			if errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("%w: unknown team %q in pattern %q", ErrInvalidCodeOwners, ownerTeam, rule.Pattern)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.teamStore.UpdateOwnershipRules(ctx, teamName, rules); err != nil {
+			return err
+		}
+		payload := map[string]any{"team_name": teamName, "rules": auditOwnershipRules(rules)}
+		result := map[string]any{"previous_rules": auditOwnershipRules(team.OwnershipRules)}
+		return s.recordAudit(ctx, AuditOperationSetOwnershipRules, teamName, payload, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	team.OwnershipRules = rules
+	return team, nil
+}
+
+func (s *Service) SetUserActive(ctx context.Context, userID string, isActive bool) (_ *User, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.SetUserActive")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.setUserActive(ctx, userID, isActive, SetUserActiveOptions{})
+}
+
+func (s *Service) setUserActive(ctx context.Context, userID string, isActive bool, opts SetUserActiveOptions) (*User, error) {
 	user, err := s.userStore.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	wasActive := user.IsActive
 	user.IsActive = isActive
-	if err := s.userStore.Update(ctx, user); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userStore.Update(ctx, user); err != nil {
+			return err
+		}
+		payload := map[string]any{"user_id": userID, "is_active": isActive, "reassign": opts.Reassign}
+		result := map[string]any{"team_name": user.TeamName, "was_active": wasActive}
+		return s.recordAudit(ctx, AuditOperationSetUserActive, userID, payload, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetUserActiveWithOptions меняет активность пользователя. При деактивации с opts.Reassign или
+// AutoReassign у его команды он заменяется во всех OPEN PR так же, как через ReassignReviewer.
+// Каждый PR обрабатывается отдельно: неудачные замены возвращаются с Err и не отменяют остальные.
+func (s *Service) SetUserActiveWithOptions(
+	ctx context.Context,
+	userID string,
+	isActive bool,
+	opts SetUserActiveOptions,
+) (_ *User, _ []Reassignment, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.SetUserActiveWithOptions")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.setUserActive(ctx, userID, isActive, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reassignments := make([]Reassignment, 0)
+	if isActive {
+		return user, reassignments, nil
+	}
+	if !opts.Reassign {
+		team, err := s.teamStore.GetByName(ctx, user.TeamName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !team.Settings.AutoReassign {
+			return user, reassignments, nil
+		}
+	}
+
+	prs, err := s.prStore.GetOpenByReviewerIDs(ctx, []string{userID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pr := range prs {
+		reassignment := Reassignment{PullRequestID: pr.ID, OldReviewerID: userID}
+
+		updated, newReviewerID, err := s.reassignReviewer(ctx, pr.ID, userID, AssignmentReasonDeactivation)
+		switch {
+		case err == nil:
+			reassignment.NewReviewerID = newReviewerID
+			reassignment.FallbackTeam = updated.Review(newReviewerID).FallbackTeam
+		case errors.Is(err, ErrNoCandidate), isStaleReassignment(err):
+			reassignment.Err = err
+		default:
+			return nil, nil, err
+		}
+		reassignments = append(reassignments, reassignment)
+	}
+
+	return user, reassignments, nil
+}
+
+// SetUserUnavailability заменяет периоды отсутствия пользователя. Пустой список их очищает.
+func (s *Service) SetUserUnavailability(ctx context.Context, userID string, windows []Unavailability) (_ *User, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.SetUserUnavailability")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, window := range windows {
+		if err := window.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.userStore.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]Unavailability(nil), windows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].From.Before(sorted[j].From)
+	})
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userStore.SetUnavailability(ctx, userID, sorted); err != nil {
+			return err
+		}
+		payload := map[string]any{"user_id": userID, "windows": auditUnavailability(sorted)}
+		result := map[string]any{"team_name": user.TeamName, "previous_windows": auditUnavailability(user.Unavailability)}
+		return s.recordAudit(ctx, AuditOperationSetUserUnavailability, userID, payload, result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	user.Unavailability = sorted
 	return user, nil
 }
 
 func (s *Service) CreatePR(ctx context.Context, prID, name, authorID string) (*PullRequest, error) {
+	return s.CreatePRWithOptions(ctx, prID, name, authorID, CreatePROptions{})
+}
+
+// CreatePRWithOptions создаёт PR и назначает ревьюверов. Если изменённые файлы подходят под
+// правила владения кодом команды автора, первым назначается один из владельцев, остальные
+// добираются из команды автора и её запасных команд.
+func (s *Service) CreatePRWithOptions(ctx context.Context, prID, name, authorID string, opts CreatePROptions) (_ *PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.CreatePR")
+	defer func() { end(err) }()
+
+	if err := requireUser(ctx, authorID); err != nil {
+		return nil, err
+	}
+
 	existing, err := s.prStore.GetByID(ctx, prID)
 	if err == nil && existing != nil {
 		return nil, ErrPRExists
@@ -66,6 +420,26 @@ func (s *Service) CreatePR(ctx context.Context, prID, name, authorID string) (*P
 		return nil, err
 	}
 
+	team, err := s.teamStore.GetByName(ctx, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	requiredReviewers := team.Settings.WithDefaults().RequiredReviewers
+	reviews := make([]Review, 0, requiredReviewers)
+	excluded := map[string]bool{authorID: true}
+
+	if len(opts.ChangedFiles) > 0 {
+		owner, err := s.selectCodeOwner(ctx, team, opts.ChangedFiles, excluded)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			reviews = append(reviews, *owner)
+			excluded[owner.ReviewerID] = true
+		}
+	}
+
 	candidates, err := s.userStore.GetActiveByTeamName(ctx, author.TeamName)
 	if err != nil {
 		return nil, err
@@ -74,29 +448,119 @@ func (s *Service) CreatePR(ctx context.Context, prID, name, authorID string) (*P
 	availableCandidates := make([]*User, 0)
 	for _, candidate := range candidates {
 
-		if candidate.ID != authorID && candidate.CanBeReviewer() {
+		if !excluded[candidate.ID] && candidate.CanBeReviewer() {
 			availableCandidates = append(availableCandidates, candidate)
 		}
 	}
 
-	reviewerIDs := selectRandomReviewers(availableCandidates, 2)
+	if count := requiredReviewers - len(reviews); count > 0 {
+		reviewerIDs, err := s.selector.Select(ctx, team, availableCandidates, count)
+		if err != nil {
+			return nil, err
+		}
+		for _, reviewerID := range reviewerIDs {
+			reviews = append(reviews, Review{ReviewerID: reviewerID})
+			excluded[reviewerID] = true
+		}
+	}
+
+	if missing := requiredReviewers - len(reviews); missing > 0 {
+		fallbackIDs, fallbackTeams, err := s.selectFromFallbackTeams(ctx, team, excluded, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, reviewerID := range fallbackIDs {
+			reviews = append(reviews, Review{ReviewerID: reviewerID, FallbackTeam: fallbackTeams[reviewerID]})
+		}
+	}
 
+	now := time.Now()
 	pr := &PullRequest{
-		ID:           prID,
-		Name:         name,
-		Status:       PullRequestStatusOpen,
-		AuthorID:     authorID,
-		ReviewersIDs: reviewerIDs,
+		ID:                prID,
+		Name:              name,
+		Status:            PullRequestStatusOpen,
+		AuthorID:          authorID,
+		RequiredReviewers: requiredReviewers,
+		CreatedAt:         now,
 	}
+	pr.assignReviewers(reviews, now)
 
-	if err := s.prStore.Create(ctx, pr); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.prStore.Create(ctx, pr); err != nil {
+			return err
+		}
+		if err := s.events.Append(ctx, newPREvent(EventPRCreated, pr)); err != nil {
+			return err
+		}
+		if err := s.recordAssignments(ctx, initialAssignments(ctx, pr)...); err != nil {
+			return err
+		}
+		payload := map[string]any{
+			"pull_request_id":   prID,
+			"pull_request_name": name,
+			"author_id":         authorID,
+			"changed_files":     opts.ChangedFiles,
+		}
+		result := map[string]any{"assigned_reviewers": pr.ReviewersIDs, "required_reviewers": requiredReviewers}
+		return s.recordAudit(ctx, AuditOperationCreatePR, prID, payload, result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.metrics.PRCreated(len(pr.ReviewersIDs))
 	return pr, nil
 }
 
+// MergePR мержит PR, если он проходит политику merge команды автора.
 func (s *Service) MergePR(ctx context.Context, prID string) (*PullRequest, error) {
+	return s.MergePRWithOptions(ctx, prID, MergeOptions{})
+}
+
+// overrideActor возвращает автора обхода политики merge: пользователя токена или пользователя
+// операции из контекста. Заявленный в запросе declared лишь подтверждает его и не может ему
+// противоречить; сам по себе он учитывается только у вызовов без токена и пользователя операции.
+func overrideActor(ctx context.Context, declared string) (string, error) {
+	principal, authenticated := PrincipalFromContext(ctx)
+	actorID := ActorFromContext(ctx)
+	if principal.UserID != "" {
+		actorID = principal.UserID
+	}
+
+	switch {
+	case actorID == "" && authenticated:
+		return "", fmt.Errorf("%w: forced merge requires a token bound to a user or an actor", ErrInvalidOverride)
+	case actorID == "":
+		return declared, nil
+	case declared != "" && declared != actorID:
+		return "", fmt.Errorf("%w: actor_id %q does not match the authenticated actor %q", ErrInvalidOverride, declared, actorID)
+	default:
+		return actorID, nil
+	}
+}
+
+func (s *Service) MergePRWithOptions(ctx context.Context, prID string, opts MergeOptions) (_ *PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.MergePR")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	if opts.Force {
+		if err := requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+		actorID, err := overrideActor(ctx, opts.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID == "" || opts.Reason == "" {
+			return nil, fmt.Errorf("%w: actor and reason are required for forced merge", ErrInvalidOverride)
+		}
+		opts.ActorID = actorID
+	}
+
 	pr, err := s.prStore.GetByID(ctx, prID)
 	if err != nil {
 		return nil, err
@@ -105,23 +569,236 @@ func (s *Service) MergePR(ctx context.Context, prID string) (*PullRequest, error
 	if pr.IsMerged() {
 		return pr, nil
 	}
+	if pr.IsClosed() {
+		return nil, ErrPRClosed
+	}
+
+	author, err := s.userStore.GetByID(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	team, err := s.teamStore.GetByName(ctx, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
 
+	if err := team.Settings.CheckMergePolicy(pr); err != nil {
+		if !opts.Force {
+			return nil, err
+		}
+		pr.MergeOverride = &MergeOverride{ActorID: opts.ActorID, Reason: opts.Reason}
+	}
+
+	mergedAt := time.Now()
 	pr.Status = PullRequestStatusMerged
-	if err := s.prStore.Update(ctx, pr); err != nil {
+	pr.MergedAt = &mergedAt
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.updatePR(ctx, pr, newPREvent(EventPRMerged, pr)); err != nil {
+			return err
+		}
+		payload := map[string]any{"pull_request_id": prID, "force": opts.Force, "actor_id": opts.ActorID, "reason": opts.Reason}
+		result := map[string]any{"status": pr.Status, "policy_overridden": pr.MergeOverride != nil}
+		return s.recordAudit(ctx, AuditOperationMergePR, prID, payload, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.PRMerged()
+	return pr, nil
+}
+
+// SubmitReview сохраняет вердикт назначенного ревьювера по открытому PR.
+func (s *Service) SubmitReview(ctx context.Context, prID, reviewerID string, state ReviewState) (_ *PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.SubmitReview")
+	defer func() { end(err) }()
+
+	if err := requireUser(ctx, reviewerID); err != nil {
+		return nil, err
+	}
+
+	if !state.IsVerdict() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReviewState, state)
+	}
+
+	pr, err := s.prStore.GetByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.checkModifiable(); err != nil {
+		return nil, err
+	}
+	if !pr.HasReviewer(reviewerID) {
+		return nil, ErrNotAssigned
+	}
+
+	previous := pr.Review(reviewerID).State
+	pr.setReviewState(reviewerID, state, time.Now())
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.updatePR(ctx, pr, newPREvent(EventPRReviewSubmitted, pr)); err != nil {
+			return err
+		}
+		payload := map[string]any{"pull_request_id": prID, "reviewer_id": reviewerID, "state": state}
+		result := map[string]any{"previous_state": previous}
+		return s.recordAudit(ctx, AuditOperationSubmitReview, prID, payload, result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return pr, nil
 }
 
-func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*PullRequest, string, error) {
+// ClosePR закрывает PR без merge. Повторное закрытие идемпотентно.
+func (s *Service) ClosePR(ctx context.Context, prID string) (_ *PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.ClosePR")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.prStore.GetByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.IsClosed() {
+		return pr, nil
+	}
+	if pr.IsMerged() {
+		return nil, ErrPRMerged
+	}
+
+	pr.Status = PullRequestStatusClosed
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.updatePR(ctx, pr, newPREvent(EventPRClosed, pr)); err != nil {
+			return err
+		}
+		result := map[string]any{"status": pr.Status, "reviewers": pr.ReviewersIDs}
+		return s.recordAudit(ctx, AuditOperationClosePR, prID, map[string]any{"pull_request_id": prID}, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// ReopenPR снова открывает закрытый PR. Ревьюверы, ставшие неактивными, заменяются
+// активными участниками их команды (кроме автора), либо снимаются, если кандидатов нет.
+// Повторное открытие идемпотентно.
+func (s *Service) ReopenPR(ctx context.Context, prID string) (_ *PullRequest, _ []Reassignment, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.ReopenPR")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		pr            *PullRequest
+		reassignments []Reassignment
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prStore.GetByID(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		reassignments = make([]Reassignment, 0)
+		if pr.CanReassign() {
+			return nil
+		}
+		if pr.IsMerged() {
+			return ErrPRMerged
+		}
+
+		pr.Status = PullRequestStatusOpen
+		excluded := map[string]bool{pr.AuthorID: true}
+		for _, reviewerID := range append([]string(nil), pr.ReviewersIDs...) {
+			reviewer, err := s.userStore.GetByID(ctx, reviewerID)
+			if err != nil {
+				return err
+			}
+			if reviewer.CanBeReviewer() {
+				continue
+			}
+
+			team, err := s.teamStore.GetByName(ctx, reviewer.TeamName)
+			if err != nil {
+				return err
+			}
+			candidates, err := s.userStore.GetActiveByTeamName(ctx, reviewer.TeamName)
+			if err != nil {
+				return err
+			}
+
+			newReviewerID, fallbackTeam, err := s.selectReplacement(ctx, team, candidates, pr, reviewerID, excluded)
+			if err != nil {
+				return err
+			}
+
+			pr.replaceReviewer(reviewerID, newReviewerID, fallbackTeam)
+			reassignments = append(reassignments, Reassignment{
+				PullRequestID: pr.ID,
+				OldReviewerID: reviewerID,
+				NewReviewerID: newReviewerID,
+				FallbackTeam:  fallbackTeam,
+			})
+		}
+
+		events := []Event{newPREvent(EventPRReopened, pr)}
+		for _, reassignment := range reassignments {
+			events = append(events, newReassignEvent(pr, reassignment))
+		}
+		if err := s.updatePR(ctx, pr, events...); err != nil {
+			return err
+		}
+		if err := s.recordReassignments(ctx, pr, AssignmentReasonReopen, reassignments...); err != nil {
+			return err
+		}
+		for _, reassignment := range reassignments {
+			if err := s.auditReassignment(ctx, AssignmentReasonReopen, reassignment); err != nil {
+				return err
+			}
+		}
+		result := map[string]any{"status": pr.Status, "reviewers": pr.ReviewersIDs}
+		return s.recordAudit(ctx, AuditOperationReopenPR, prID, map[string]any{"pull_request_id": prID}, result)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.countReassignments(AssignmentReasonReopen, reassignments)
+	return pr, reassignments, nil
+}
+
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (_ *PullRequest, _ string, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.ReassignReviewer")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, "", err
+	}
+
+	return s.reassignReviewer(ctx, prID, oldReviewerID, AssignmentReasonReassign)
+}
+
+// reassignReviewer заменяет ревьювера и записывает замену в историю с причиной reason.
+func (s *Service) reassignReviewer(
+	ctx context.Context,
+	prID, oldReviewerID string,
+	reason AssignmentReason,
+) (*PullRequest, string, error) {
 	pr, err := s.prStore.GetByID(ctx, prID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	if !pr.CanReassign() {
-		return nil, "", ErrPRMerged
+	if err := pr.checkModifiable(); err != nil {
+		return nil, "", err
 	}
 
 	if !pr.HasReviewer(oldReviewerID) {
@@ -133,45 +810,219 @@ func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID stri
 		return nil, "", ErrNotFound
 	}
 
+	team, err := s.teamStore.GetByName(ctx, oldReviewer.TeamName)
+	if err != nil {
+		return nil, "", err
+	}
+
 	candidates, err := s.userStore.GetActiveByTeamName(ctx, oldReviewer.TeamName)
 	if err != nil {
 		return nil, "", err
 	}
 
-	availableCandidates := make([]*User, 0)
-	assignedMap := make(map[string]bool)
-	for _, reviewerID := range pr.ReviewersIDs {
-		assignedMap[reviewerID] = true
+	newReviewerID, fallbackTeam, err := s.selectReplacement(ctx, team, candidates, pr, oldReviewerID, map[string]bool{pr.AuthorID: true})
+	if err != nil {
+		return nil, "", err
+	}
+	if newReviewerID == "" {
+		s.metrics.NoCandidate()
+		return nil, "", ErrNoCandidate
 	}
 
-	for _, candidate := range candidates {
-		if candidate.ID != oldReviewerID && !assignedMap[candidate.ID] && candidate.CanBeReviewer() {
-			availableCandidates = append(availableCandidates, candidate)
+	pr.replaceReviewer(oldReviewerID, newReviewerID, fallbackTeam)
+
+	reassignment := Reassignment{
+		PullRequestID: pr.ID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: newReviewerID,
+		FallbackTeam:  fallbackTeam,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.updatePR(ctx, pr, newReassignEvent(pr, reassignment)); err != nil {
+			return err
 		}
+		if err := s.recordReassignments(ctx, pr, reason, reassignment); err != nil {
+			return err
+		}
+		return s.auditReassignment(ctx, reason, reassignment)
+	})
+	if err != nil {
+		return nil, "", err
 	}
 
-	if len(availableCandidates) == 0 {
-		return nil, "", ErrNoCandidate
+	s.metrics.ReviewerReassigned(reason)
+	return pr, newReviewerID, nil
+}
+
+// DeactivateTeamUsers в одной транзакции деактивирует участников команды и заменяет их
+// во всех OPEN PR на активных кандидатов из этой команды, либо снимает, если кандидатов нет.
+func (s *Service) DeactivateTeamUsers(ctx context.Context, teamName string, userIDs []string) (_ []*User, _ []Reassignment, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.DeactivateTeamUsers")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, nil, err
 	}
 
-	newReviewer := availableCandidates[rand.Intn(len(availableCandidates))]
+	deactivating := make(map[string]bool, len(userIDs))
+	uniqueIDs := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if !deactivating[userID] {
+			deactivating[userID] = true
+			uniqueIDs = append(uniqueIDs, userID)
+		}
+	}
 
-	for i, reviewerID := range pr.ReviewersIDs {
-		if reviewerID == oldReviewerID {
-			pr.ReviewersIDs[i] = newReviewer.ID
-			break
+	var (
+		users         []*User
+		reassignments []Reassignment
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.teamStore.GetByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		users, err = s.userStore.SetActiveByTeam(ctx, teamName, uniqueIDs, false)
+		if err != nil {
+			return err
+		}
+		if len(users) != len(uniqueIDs) {
+			return fmt.Errorf("%w: some users are not members of team %s", ErrNotFound, teamName)
+		}
+
+		prs, err := s.prStore.GetOpenByReviewerIDs(ctx, uniqueIDs)
+		if err != nil {
+			return err
 		}
+
+		candidates, err := s.userStore.GetActiveByTeamName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		reassignments = make([]Reassignment, 0)
+		for _, pr := range prs {
+			var prReassignments []Reassignment
+			excluded := map[string]bool{pr.AuthorID: true}
+			for _, reviewerID := range append([]string(nil), pr.ReviewersIDs...) {
+				if !deactivating[reviewerID] {
+					continue
+				}
+
+				newReviewerID, fallbackTeam, err := s.selectReplacement(ctx, team, candidates, pr, reviewerID, excluded)
+				if err != nil {
+					return err
+				}
+
+				pr.replaceReviewer(reviewerID, newReviewerID, fallbackTeam)
+				prReassignments = append(prReassignments, Reassignment{
+					PullRequestID: pr.ID,
+					OldReviewerID: reviewerID,
+					NewReviewerID: newReviewerID,
+					FallbackTeam:  fallbackTeam,
+				})
+			}
+
+			events := make([]Event, len(prReassignments))
+			for i, reassignment := range prReassignments {
+				events[i] = newReassignEvent(pr, reassignment)
+			}
+
+			// обновление сразу видно следующим выборам, поэтому нагрузка распределяется по всей пачке
+			if err := s.updatePR(ctx, pr, events...); err != nil {
+				return err
+			}
+			if err := s.recordReassignments(ctx, pr, AssignmentReasonDeactivation, prReassignments...); err != nil {
+				return err
+			}
+			for _, reassignment := range prReassignments {
+				if err := s.auditReassignment(ctx, AssignmentReasonDeactivation, reassignment); err != nil {
+					return err
+				}
+			}
+			reassignments = append(reassignments, prReassignments...)
+		}
+
+		for _, user := range users {
+			replaced := make([]map[string]any, 0)
+			for _, reassignment := range reassignments {
+				if reassignment.OldReviewerID == user.ID {
+					replaced = append(replaced, map[string]any{
+						"pull_request_id": reassignment.PullRequestID,
+						"new_reviewer_id": reassignment.NewReviewerID,
+					})
+				}
+			}
+			payload := map[string]any{"team_name": teamName, "user_ids": uniqueIDs}
+			result := map[string]any{"reassignments": replaced}
+			if err := s.recordAudit(ctx, AuditOperationDeactivateUser, user.ID, payload, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := s.prStore.Update(ctx, pr); err != nil {
-		return nil, "", err
+	s.countReassignments(AssignmentReasonDeactivation, reassignments)
+	return users, reassignments, nil
+}
+
+func (s *Service) GetPR(ctx context.Context, prID string) (_ *PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetPR")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	return s.prStore.GetByID(ctx, prID)
+}
+
+// ListPRs возвращает страницу PR, подходящих под фильтр.
+func (s *Service) ListPRs(ctx context.Context, filter PRFilter) (_ PRPage, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.ListPRs")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return PRPage{}, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return PRPage{}, err
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultPRPageSize
+	}
+
+	// лишний PR показывает, что за страницей есть продолжение
+	filter.Limit = limit + 1
+	prs, err := s.prStore.List(ctx, filter)
+	if err != nil {
+		return PRPage{}, err
 	}
 
-	return pr, newReviewer.ID, nil
+	page := PRPage{PullRequests: prs}
+	if len(prs) > limit {
+		page.PullRequests = prs[:limit]
+		last := page.PullRequests[limit-1]
+		page.Next = &PRCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
 }
 
-func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]*PullRequest, error) {
-	_, err := s.userStore.GetByID(ctx, userID)
+func (s *Service) GetUserReviews(ctx context.Context, userID string) (_ []*PullRequest, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetUserReviews")
+	defer func() { end(err) }()
+
+	if err := requireUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	_, err = s.userStore.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -181,33 +1032,536 @@ func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]*PullReq
 		return nil, err
 	}
 
-	return prs, nil
+	// закрытые PR ревью больше не ждут
+	reviews := make([]*PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if !pr.IsClosed() {
+			reviews = append(reviews, pr)
+		}
+	}
+	return reviews, nil
+}
+
+// GetPendingReviews возвращает открытые PR, где ревью пользователя ещё в состоянии PENDING.
+func (s *Service) GetPendingReviews(ctx context.Context, userID string) ([]*PullRequest, error) {
+	prs, err := s.GetUserReviews(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if pr.CanReassign() && pr.Review(userID).State == ReviewStatePending {
+			pending = append(pending, pr)
+		}
+	}
+	return pending, nil
+}
+
+// GetStatistics считает показатели PR и назначений за окно фильтра.
+func (s *Service) GetStatistics(ctx context.Context, filter StatisticsFilter) (_ *Statistics, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetStatistics")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.TeamName != "" {
+		if _, err := s.teamStore.GetByName(ctx, filter.TeamName); err != nil {
+			return nil, err
+		}
+	}
+
+	stats, err := s.prStore.GetStatistics(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.sort()
+	return stats, nil
 }
 
-func (s *Service) GetStatistics(ctx context.Context) (map[string]int, error) {
-	return s.prStore.GetStatistics(ctx)
+// GetOverduePRs возвращает открытые PR с ревью, которые ждут дольше SLA команды автора.
+func (s *Service) GetOverduePRs(ctx context.Context) (_ []OverduePR, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetOverduePRs")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	prs, err := s.prStore.GetOverdue(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make(map[string]*Team)
+	result := make([]OverduePR, 0, len(prs))
+	for _, pr := range prs {
+		team, ok := teams[pr.AuthorID]
+		if !ok {
+			author, err := s.userStore.GetByID(ctx, pr.AuthorID)
+			if err != nil {
+				return nil, err
+			}
+			team, err = s.teamStore.GetByName(ctx, author.TeamName)
+			if err != nil {
+				return nil, err
+			}
+			teams[pr.AuthorID] = team
+		}
+
+		sla := team.Settings.ReviewSLA
+		if reviews := pr.OverdueReviews(sla, now); len(reviews) > 0 {
+			result = append(result, OverduePR{PullRequest: pr, SLA: sla, Reviews: reviews})
+		}
+	}
+	return result, nil
 }
 
-func selectRandomReviewers(candidates []*User, maxCount int) []string {
-	if len(candidates) == 0 {
-		return []string{}
+// HandleOverdueReviews эскалирует или переназначает просроченные ревью. Эскалация
+// выполняется один раз на ревью, переназначение перезапускает отсчёт SLA для нового ревьювера.
+func (s *Service) HandleOverdueReviews(ctx context.Context, action SLAAction) (_ SLAReport, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.HandleOverdueReviews")
+	defer func() { end(err) }()
+
+	if err := requireAdmin(ctx); err != nil {
+		return SLAReport{}, err
+	}
+
+	var report SLAReport
+	if !action.IsValid() {
+		return report, fmt.Errorf("%w: unknown SLA action %q", ErrInvalidSettings, action)
 	}
 
-	count := maxCount
-	if len(candidates) < maxCount {
-		count = len(candidates)
+	overdue, err := s.GetOverduePRs(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, item := range overdue {
+		for _, review := range item.Reviews {
+			if action == SLAActionReassign {
+				_, _, err := s.reassignReviewer(ctx, item.PullRequest.ID, review.ReviewerID, AssignmentReasonSLA)
+				if err == nil {
+					report.Reassigned++
+					continue
+				}
+				if isStaleReassignment(err) {
+					continue
+				}
+				if !errors.Is(err, ErrNoCandidate) {
+					return report, err
+				}
+				// замены нет, поэтому ревью эскалируется
+			}
+
+			escalated, err := s.escalateReview(ctx, item.PullRequest.ID, review.ReviewerID, item.SLA)
+			if err != nil {
+				return report, err
+			}
+			if escalated {
+				report.Escalated++
+			}
+		}
 	}
+	return report, nil
+}
 
-	shuffled := make([]*User, len(candidates))
-	copy(shuffled, candidates)
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+// escalateReview отмечает просроченное ревью эскалированным и пишет событие pr.review_overdue.
+// Уже эскалированное или изменившееся с момента выборки ревью пропускается.
+func (s *Service) escalateReview(ctx context.Context, prID, reviewerID string, sla time.Duration) (bool, error) {
+	escalated := false
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		pr, err := s.prStore.GetByID(ctx, prID)
+		if err != nil {
+			return err
+		}
+		if !pr.CanReassign() || !pr.HasReviewer(reviewerID) {
+			return nil
+		}
+
+		review := pr.Review(reviewerID)
+		if review.State != ReviewStatePending || review.EscalatedAt != nil {
+			return nil
+		}
+
+		pr.markEscalated(reviewerID, time.Now())
+		event := newPREvent(EventPRReviewOverdue, pr)
+		event.Escalation = &Escalation{
+			ReviewerID: reviewerID,
+			AssignedAt: review.AssignedAt,
+			DueAt:      review.AssignedAt.Add(sla),
+		}
+		if err := s.updatePR(ctx, pr, event); err != nil {
+			return err
+		}
+		escalated = true
+		return nil
+	})
+	return escalated, err
+}
+
+// GetPRHistory возвращает историю назначений ревьюверов PR в порядке назначения.
+func (s *Service) GetPRHistory(ctx context.Context, prID string) (_ []Assignment, err error) {
+	ctx, end := s.tracer.Start(ctx, "Service.GetPRHistory")
+	defer func() { end(err) }()
+
+	if err := s.authorizePR(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	if s.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	if _, err := s.prStore.GetByID(ctx, prID); err != nil {
+		return nil, err
+	}
+	return s.history.GetByPullRequestID(ctx, prID)
+}
+
+func (s *Service) RegisterWebhook(ctx context.Context, subscription *WebhookSubscription) error {
+	if s.webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	return s.webhooks.CreateSubscription(ctx, subscription)
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.webhooks.ListSubscriptions(ctx)
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	if s.webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.webhooks.DeleteSubscription(ctx, id)
+}
+
+func (s *Service) LinkExternalAccount(ctx context.Context, account ExternalAccount) error {
+	if s.accounts == nil {
+		return ErrIntegrationsDisabled
+	}
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	account.Login = normalizeLogin(account.Login)
+	if err := account.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.userStore.GetByID(ctx, account.UserID); err != nil {
+		return err
+	}
+	return s.accounts.Link(ctx, account)
+}
+
+// ResolveExternalUser возвращает ID пользователя по логину во внешней системе.
+func (s *Service) ResolveExternalUser(ctx context.Context, provider, login string) (string, error) {
+	if s.accounts == nil {
+		return "", ErrIntegrationsDisabled
+	}
+
+	userID, err := s.accounts.ResolveUserID(ctx, provider, normalizeLogin(login))
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%w: %s login %q", ErrUnknownAccount, provider, login)
+	}
+	return userID, err
+}
+
+// ExternalLogins возвращает логины пользователей во внешней системе по их ID.
+func (s *Service) ExternalLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	if s.accounts == nil {
+		return nil, ErrIntegrationsDisabled
+	}
+	return s.accounts.LoginsByUserIDs(ctx, provider, userIDs)
+}
+
+// updatePR сохраняет PR и события о его изменении в одной транзакции.
+func (s *Service) updatePR(ctx context.Context, pr *PullRequest, events ...Event) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.prStore.Update(ctx, pr); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := s.events.Append(ctx, event); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+}
+
+// recordAssignments добавляет назначения в историю, если она включена.
+func (s *Service) recordAssignments(ctx context.Context, assignments ...Assignment) error {
+	if s.history == nil || len(assignments) == 0 {
+		return nil
+	}
+	return s.history.Append(ctx, assignments...)
+}
+
+// recordReassignments закрывает в истории назначения заменённых ревьюверов и добавляет
+// назначения их замен. Вызывается в транзакции вместе с сохранением PR.
+func (s *Service) recordReassignments(
+	ctx context.Context,
+	pr *PullRequest,
+	reason AssignmentReason,
+	reassignments ...Reassignment,
+) error {
+	if s.history == nil {
+		return nil
+	}
+
+	actorID := ActorFromContext(ctx)
+	for _, reassignment := range reassignments {
+		at := time.Now()
+		if reassignment.NewReviewerID != "" {
+			at = pr.Review(reassignment.NewReviewerID).AssignedAt
+		}
 
-	result := make([]string, count)
-	for i := 0; i < count; i++ {
-		result[i] = shuffled[i].ID
+		if err := s.history.Close(ctx, pr.ID, reassignment.OldReviewerID, at); err != nil {
+			return err
+		}
+		if reassignment.NewReviewerID == "" {
+			continue
+		}
+
+		err := s.history.Append(ctx, Assignment{
+			PullRequestID:      pr.ID,
+			ReviewerID:         reassignment.NewReviewerID,
+			AssignedAt:         at,
+			Reason:             reason,
+			ReplacedReviewerID: reassignment.OldReviewerID,
+			ActorID:            actorID,
+		})
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// selectReplacement выбирает замену ревьюверу oldReviewerID: активного кандидата, который ещё
+// не назначен на PR и не входит в excluded, а если таких нет - кандидата из запасных команд.
+// Вместе с ID возвращается запасная команда нового ревьювера. Пустой ID означает, что кандидатов нет.
+func (s *Service) selectReplacement(
+	ctx context.Context,
+	team *Team,
+	candidates []*User,
+	pr *PullRequest,
+	oldReviewerID string,
+	excluded map[string]bool,
+) (string, string, error) {
+	available := make([]*User, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !excluded[candidate.ID] && !pr.HasReviewer(candidate.ID) && candidate.CanBeReviewer() {
+			available = append(available, candidate)
+		}
+	}
+	if len(available) > 0 {
+		selected, err := s.selector.Select(ctx, team, available, 1)
+		if err != nil {
+			return "", "", err
+		}
+		if len(selected) > 0 {
+			// замена из команды снятого ревьювера наследует его запасную команду
+			return selected[0], pr.Review(oldReviewerID).FallbackTeam, nil
+		}
+	}
+
+	skipped := make(map[string]bool, len(excluded)+len(pr.ReviewersIDs))
+	for userID := range excluded {
+		skipped[userID] = true
+	}
+	for _, reviewerID := range pr.ReviewersIDs {
+		skipped[reviewerID] = true
+	}
+
+	selected, fallbackTeams, err := s.selectFromFallbackTeams(ctx, team, skipped, 1)
+	if err != nil {
+		return "", "", err
+	}
+	if len(selected) == 0 {
+		return "", "", nil
+	}
+	return selected[0], fallbackTeams[selected[0]], nil
+}
+
+// selectFromFallbackTeams добирает до count ревьюверов из запасных команд team по порядку,
+// пропуская пользователей из excluded. Для каждого выбранного возвращает его команду.
+func (s *Service) selectFromFallbackTeams(
+	ctx context.Context,
+	team *Team,
+	excluded map[string]bool,
+	count int,
+) ([]string, map[string]string, error) {
+	selected := make([]string, 0, count)
+	fallbackTeams := make(map[string]string, count)
+	for _, name := range team.Settings.FallbackTeams {
+		if len(selected) == count {
+			break
+		}
+
+		fallbackTeam, err := s.teamStore.GetByName(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		candidates, err := s.userStore.GetActiveByTeamName(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		available := make([]*User, 0, len(candidates))
+		for _, candidate := range candidates {
+			if !excluded[candidate.ID] && fallbackTeams[candidate.ID] == "" && candidate.CanBeReviewer() {
+				available = append(available, candidate)
+			}
+		}
+		if len(available) == 0 {
+			continue
+		}
+
+		reviewerIDs, err := s.selector.Select(ctx, fallbackTeam, available, count-len(selected))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reviewerID := range reviewerIDs {
+			selected = append(selected, reviewerID)
+			fallbackTeams[reviewerID] = name
+		}
+	}
+	return selected, fallbackTeams, nil
+}
+
+// selectCodeOwner выбирает одного активного владельца изменённых файлов по правилам команды,
+// пропуская пользователей из excluded. nil означает, что подходящих владельцев нет.
+func (s *Service) selectCodeOwner(
+	ctx context.Context,
+	team *Team,
+	changedFiles []string,
+	excluded map[string]bool,
+) (*Review, error) {
+	owners := make([]*User, 0)
+	patterns := make(map[string]string)
+	addOwner := func(user *User, pattern string) {
+		if _, ok := patterns[user.ID]; ok || excluded[user.ID] || !user.CanBeReviewer() {
+			return
+		}
+		patterns[user.ID] = pattern
+		owners = append(owners, user)
+	}
+
+	for _, rule := range matchOwnershipRules(team.OwnershipRules, changedFiles) {
+		for _, userID := range rule.Users {
+			user, err := s.userStore.GetByID(ctx, userID)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			addOwner(user, rule.Pattern)
+		}
+		for _, ownerTeam := range rule.Teams {
+			members, err := s.userStore.GetActiveByTeamName(ctx, ownerTeam)
+			if err != nil {
+				return nil, err
+			}
+			for _, member := range members {
+				addOwner(member, rule.Pattern)
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return nil, nil
+	}
+
+	selected, err := s.selector.Select(ctx, team, owners, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+	return &Review{ReviewerID: selected[0], OwnerPattern: patterns[selected[0]]}, nil
+}
+
+// checkFallbackTeams проверяет, что запасные команды существуют и не совпадают с самой командой.
+func (s *Service) checkFallbackTeams(ctx context.Context, name string, fallbackTeams []string) error {
+	for _, fallbackTeam := range fallbackTeams {
+		if fallbackTeam == name {
+			return fmt.Errorf("%w: team %s cannot be its own fallback", ErrInvalidSettings, name)
+		}
+		_, err := s.teamStore.GetByName(ctx, fallbackTeam)
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: unknown fallback team %q", ErrInvalidSettings, fallbackTeam)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countReassignments учитывает замены ревьюверов; снятие без замены считается отсутствием кандидата.
+func (s *Service) countReassignments(reason AssignmentReason, reassignments []Reassignment) {
+	for _, reassignment := range reassignments {
+		if reassignment.NewReviewerID == "" {
+			s.metrics.NoCandidate()
+			continue
+		}
+		s.metrics.ReviewerReassigned(reason)
+	}
+}
+
+// isStaleReassignment сообщает, что PR изменился после выборки и ревьювера уже нельзя заменить.
+func isStaleReassignment(err error) bool {
+	return errors.Is(err, ErrNotAssigned) || errors.Is(err, ErrPRMerged) || errors.Is(err, ErrPRClosed)
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type noEvents struct{}
+
+func (noEvents) Append(context.Context, Event) error {
+	return nil
+}
+
+type noMetrics struct{}
+
+func (noMetrics) PRCreated(int)                       {}
+func (noMetrics) PRMerged()                           {}
+func (noMetrics) ReviewerReassigned(AssignmentReason) {}
+func (noMetrics) NoCandidate()                        {}
+
+type noTracer struct{}
 
-	return result
+func (noTracer) Start(ctx context.Context, _ string) (context.Context, func(error)) {
+	return ctx, func(error) {}
 }
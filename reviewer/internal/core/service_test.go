@@ -0,0 +1,1509 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/core"
+)
+
+func newTestService(t *testing.T, members ...string) (*core.Service, *memory.Storage) {
+	t.Helper()
+
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithTransactor(storage))
+
+	users := make([]core.User, len(members))
+	for i, id := range members {
+		users[i] = core.User{ID: id, Username: id, TeamName: "backend", IsActive: true}
+	}
+	if err := service.CreateTeam(context.Background(), "backend", users); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	return service, storage
+}
+
+func TestCreatePR_PrefersLeastLoadedReviewers(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if _, err := service.CreatePR(ctx, fmt.Sprintf("pr-%d", i), "Feature", "u1"); err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+
+		loads, err := storage.PR.CountOpenReviews(ctx, []string{"u2", "u3", "u4"})
+		if err != nil {
+			t.Fatalf("failed to count open reviews: %v", err)
+		}
+
+		lowest, highest := loads["u2"], loads["u2"]
+		for _, id := range []string{"u3", "u4"} {
+			lowest = min(lowest, loads[id])
+			highest = max(highest, loads[id])
+		}
+		if highest-lowest > 1 {
+			t.Fatalf("load is uneven after %d PRs: %v", i+1, loads)
+		}
+	}
+}
+
+func TestCreatePR_IgnoresMergedReviews(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	// каждый PR сразу мержится, поэтому открытых ревью ни у кого не остаётся
+	// и выбор должен со временем охватить всех кандидатов
+	seen := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		pr, err := service.CreatePR(ctx, fmt.Sprintf("pr-open-%d", i), "Feature", "u1")
+		if err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+		for _, id := range pr.ReviewersIDs {
+			seen[id] = true
+		}
+		if _, err := service.MergePR(ctx, pr.ID); err != nil {
+			t.Fatalf("failed to merge PR: %v", err)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected every candidate to be picked eventually, got %v", seen)
+	}
+}
+
+func TestReassignReviewer_PrefersLeastLoaded(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4", "u5")
+	ctx := context.Background()
+
+	setActive := func(isActive bool, ids ...string) {
+		for _, id := range ids {
+			if _, err := service.SetUserActive(ctx, id, isActive); err != nil {
+				t.Fatalf("failed to set user %s active=%v: %v", id, isActive, err)
+			}
+		}
+	}
+
+	// pr-1 достаётся u2 и u3
+	setActive(false, "u4", "u5")
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	// u1 и u4 получают по открытому ревью, u5 остаётся свободным
+	setActive(true, "u4")
+	setActive(false, "u3")
+	if _, err := service.CreatePR(ctx, "pr-2", "Feature", "u2"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	setActive(true, "u3", "u5")
+
+	_, newReviewerID, err := service.ReassignReviewer(ctx, "pr-1", "u2")
+	if err != nil {
+		t.Fatalf("failed to reassign reviewer: %v", err)
+	}
+	if newReviewerID != "u5" {
+		t.Errorf("expected least loaded u5 to be picked, got %s", newReviewerID)
+	}
+}
+
+func TestReassignReviewer_SkipsAuthor(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3")
+	ctx := context.Background()
+
+	// автор u1 в команде ревьюверов и единственный, кто не назначен на PR
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if len(pr.ReviewersIDs) != 2 {
+		t.Fatalf("expected 2 reviewers, got %v", pr.ReviewersIDs)
+	}
+
+	if _, newReviewerID, err := service.ReassignReviewer(ctx, "pr-1", "u2"); !errors.Is(err, core.ErrNoCandidate) {
+		t.Errorf("expected ErrNoCandidate instead of the author, got %q, %v", newReviewerID, err)
+	}
+}
+
+func TestCreatePR_HonorsRequiredReviewers(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR)
+	ctx := context.Background()
+
+	members := func(team string, ids ...string) []core.User {
+		result := make([]core.User, len(ids))
+		for i, id := range ids {
+			result[i] = core.User{ID: id, Username: id, TeamName: team, IsActive: true}
+		}
+		return result
+	}
+
+	err := service.CreateTeamWithSettings(ctx, "security", members("security", "s1", "s2", "s3"), core.TeamSettings{RequiredReviewers: 3})
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	err = service.CreateTeamWithSettings(ctx, "docs", members("docs", "d1", "d2", "d3"), core.TeamSettings{RequiredReviewers: 1})
+	if err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	pr, err := service.CreatePR(ctx, "pr-sec", "Rotate keys", "s1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if len(pr.ReviewersIDs) != 2 || pr.RequiredReviewers != 3 || pr.MissingReviewers() != 1 {
+		t.Errorf("expected 2 of 3 reviewers with 1 missing, got %v (required %d)", pr.ReviewersIDs, pr.RequiredReviewers)
+	}
+
+	pr, err = service.CreatePR(ctx, "pr-docs", "Fix typo", "d1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if len(pr.ReviewersIDs) != 1 || pr.MissingReviewers() != 0 {
+		t.Errorf("expected exactly 1 reviewer, got %v", pr.ReviewersIDs)
+	}
+
+	stored, err := service.GetTeam(ctx, "docs")
+	if err != nil {
+		t.Fatalf("failed to get team: %v", err)
+	}
+	if stored.Settings.RequiredReviewers != 1 {
+		t.Errorf("expected required reviewers to be stored, got %d", stored.Settings.RequiredReviewers)
+	}
+
+	zero := 0
+	if _, err := service.UpdateTeamSettings(ctx, "docs", core.TeamSettingsPatch{RequiredReviewers: &zero}); !errors.Is(err, core.ErrInvalidSettings) {
+		t.Errorf("expected ErrInvalidSettings for zero reviewers, got %v", err)
+	}
+}
+
+func TestDeactivateTeamUsers_ReassignsOpenPRs(t *testing.T) {
+	ids := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		ids = append(ids, fmt.Sprintf("u%02d", i))
+	}
+	service, storage := newTestService(t, ids...)
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		if _, err := service.CreatePR(ctx, fmt.Sprintf("pr-%d", i), "Feature", ids[i%len(ids)]); err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+	}
+	merged, err := service.MergePR(ctx, "pr-0")
+	if err != nil {
+		t.Fatalf("failed to merge PR: %v", err)
+	}
+
+	deactivated := ids[:10]
+	users, reassignments, err := service.DeactivateTeamUsers(ctx, "backend", deactivated)
+	if err != nil {
+		t.Fatalf("failed to deactivate users: %v", err)
+	}
+	if len(users) != len(deactivated) {
+		t.Errorf("expected %d deactivated users, got %d", len(deactivated), len(users))
+	}
+	if len(reassignments) == 0 {
+		t.Fatal("expected some reassignments")
+	}
+
+	for _, reassignment := range reassignments {
+		pr, err := storage.PR.GetByID(ctx, reassignment.PullRequestID)
+		if err != nil {
+			t.Fatalf("failed to get PR: %v", err)
+		}
+		for _, reviewerID := range pr.ReviewersIDs {
+			for _, id := range deactivated {
+				if reviewerID == id {
+					t.Errorf("PR %s still has deactivated reviewer %s", pr.ID, id)
+				}
+			}
+			if reviewerID == pr.AuthorID {
+				t.Errorf("PR %s got its author as reviewer", pr.ID)
+			}
+		}
+		if reassignment.NewReviewerID == "" {
+			t.Errorf("expected replacement for %s in %s", reassignment.OldReviewerID, pr.ID)
+		}
+	}
+
+	// merged PR не трогаем
+	after, _ := storage.PR.GetByID(ctx, "pr-0")
+	if len(after.ReviewersIDs) != len(merged.ReviewersIDs) {
+		t.Errorf("merged PR reviewers changed: %v -> %v", merged.ReviewersIDs, after.ReviewersIDs)
+	}
+}
+
+func TestDeactivateTeamUsers_DropsWhenNoCandidates(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if len(pr.ReviewersIDs) != 2 {
+		t.Fatalf("expected 2 reviewers, got %v", pr.ReviewersIDs)
+	}
+
+	_, reassignments, err := service.DeactivateTeamUsers(ctx, "backend", []string{"u2", "u3"})
+	if err != nil {
+		t.Fatalf("failed to deactivate users: %v", err)
+	}
+	if len(reassignments) != 2 {
+		t.Fatalf("expected 2 reassignments, got %+v", reassignments)
+	}
+	for _, reassignment := range reassignments {
+		if reassignment.NewReviewerID != "" {
+			t.Errorf("expected reviewer to be dropped, got %+v", reassignment)
+		}
+	}
+
+	reviews, err := service.GetUserReviews(ctx, "u2")
+	if err != nil {
+		t.Fatalf("failed to get reviews: %v", err)
+	}
+	if len(reviews) != 0 {
+		t.Errorf("expected u2 to have no reviews, got %d", len(reviews))
+	}
+}
+
+func TestDeactivateTeamUsers_RollsBackOnUnknownUser(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3")
+	ctx := context.Background()
+
+	_, _, err := service.DeactivateTeamUsers(ctx, "backend", []string{"u2", "ghost"})
+	if !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	user, err := storage.User.GetByID(ctx, "u2")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if !user.IsActive {
+		t.Error("u2 should stay active after rollback")
+	}
+}
+
+func TestClosePR_RejectsFurtherChanges(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to close PR: %v", err)
+	}
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("expected repeated close to be idempotent, got %v", err)
+	}
+
+	if _, err := service.MergePR(ctx, "pr-1"); !errors.Is(err, core.ErrPRClosed) {
+		t.Errorf("expected ErrPRClosed on merge, got %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(ctx, "pr-1", pr.ReviewersIDs[0]); !errors.Is(err, core.ErrPRClosed) {
+		t.Errorf("expected ErrPRClosed on reassign, got %v", err)
+	}
+}
+
+func TestReopenPR_ReplacesInactiveReviewers(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to close PR: %v", err)
+	}
+
+	inactive, kept := pr.ReviewersIDs[0], pr.ReviewersIDs[1]
+	if _, err := service.SetUserActive(ctx, inactive, false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+
+	reopened, reassignments, err := service.ReopenPR(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to reopen PR: %v", err)
+	}
+	if reopened.Status != core.PullRequestStatusOpen {
+		t.Fatalf("expected OPEN status, got %s", reopened.Status)
+	}
+	if len(reassignments) != 1 || reassignments[0].OldReviewerID != inactive {
+		t.Fatalf("expected only %s to be replaced, got %+v", inactive, reassignments)
+	}
+
+	replacement := reassignments[0].NewReviewerID
+	if replacement == "" || replacement == "u1" || replacement == kept || replacement == inactive {
+		t.Errorf("unexpected replacement %q", replacement)
+	}
+	if !reopened.HasReviewer(kept) || !reopened.HasReviewer(replacement) || reopened.HasReviewer(inactive) {
+		t.Errorf("unexpected reviewers after reopen: %v", reopened.ReviewersIDs)
+	}
+
+	if _, err := service.MergePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to merge PR: %v", err)
+	}
+	if _, _, err := service.ReopenPR(ctx, "pr-1"); !errors.Is(err, core.ErrPRMerged) {
+		t.Errorf("expected ErrPRMerged on reopen of merged PR, got %v", err)
+	}
+}
+
+func TestSubmitReview_StoresVerdict(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	approver, other := pr.ReviewersIDs[0], pr.ReviewersIDs[1]
+
+	if _, err := service.SubmitReview(ctx, "pr-1", approver, core.ReviewStatePending); !errors.Is(err, core.ErrInvalidReviewState) {
+		t.Errorf("expected ErrInvalidReviewState for PENDING verdict, got %v", err)
+	}
+	if _, err := service.SubmitReview(ctx, "pr-1", "u1", core.ReviewStateApproved); !errors.Is(err, core.ErrNotAssigned) {
+		t.Errorf("expected ErrNotAssigned for author, got %v", err)
+	}
+
+	if _, err := service.SubmitReview(ctx, "pr-1", approver, core.ReviewStateApproved); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if state := stored.Review(approver).State; state != core.ReviewStateApproved {
+		t.Errorf("expected %s to have approved, got %s", approver, state)
+	}
+	if state := stored.Review(other).State; state != core.ReviewStatePending {
+		t.Errorf("expected %s to stay pending, got %s", other, state)
+	}
+
+	pending, err := service.GetPendingReviews(ctx, approver)
+	if err != nil {
+		t.Fatalf("failed to get pending reviews: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending reviews for %s, got %d", approver, len(pending))
+	}
+	if pending, _ := service.GetPendingReviews(ctx, other); len(pending) != 1 {
+		t.Errorf("expected 1 pending review for %s, got %d", other, len(pending))
+	}
+
+	// новый ревьювер начинает с PENDING, вердикт оставшегося сохраняется
+	if _, _, err := service.ReassignReviewer(ctx, "pr-1", other); err != nil {
+		t.Fatalf("failed to reassign reviewer: %v", err)
+	}
+	stored, _ = storage.PR.GetByID(ctx, "pr-1")
+	if state := stored.Review(approver).State; state != core.ReviewStateApproved {
+		t.Errorf("expected approval to survive reassignment, got %s", state)
+	}
+	if len(stored.Reviews) != 2 {
+		t.Errorf("expected reviews of exactly 2 reviewers, got %+v", stored.Reviews)
+	}
+}
+
+func TestMergePR_EnforcesRequiredApprovals(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	approvals := 2
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{RequiredApprovals: &approvals}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	first, second := pr.ReviewersIDs[0], pr.ReviewersIDs[1]
+
+	if _, err := service.SubmitReview(ctx, "pr-1", first, core.ReviewStateApproved); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	if _, err := service.MergePR(ctx, "pr-1"); !errors.Is(err, core.ErrMergeBlocked) {
+		t.Fatalf("expected ErrMergeBlocked with 1 of 2 approvals, got %v", err)
+	}
+
+	if _, err := service.SubmitReview(ctx, "pr-1", second, core.ReviewStateChangesRequested); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	if _, err := service.MergePR(ctx, "pr-1"); !errors.Is(err, core.ErrMergeBlocked) {
+		t.Fatalf("expected ErrMergeBlocked with changes requested, got %v", err)
+	}
+
+	if _, err := service.SubmitReview(ctx, "pr-1", second, core.ReviewStateApproved); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	merged, err := service.MergePR(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("expected merge to pass the policy, got %v", err)
+	}
+	if merged.MergeOverride != nil {
+		t.Errorf("expected no override for a compliant merge, got %+v", merged.MergeOverride)
+	}
+
+	if _, err := service.CreatePR(ctx, "pr-2", "Hotfix", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.MergePRWithOptions(ctx, "pr-2", core.MergeOptions{Force: true, ActorID: "u4"}); !errors.Is(err, core.ErrInvalidOverride) {
+		t.Fatalf("expected ErrInvalidOverride without reason, got %v", err)
+	}
+	if _, err := service.MergePRWithOptions(ctx, "pr-2", core.MergeOptions{Force: true, ActorID: "u4", Reason: "incident"}); err != nil {
+		t.Fatalf("failed to force merge: %v", err)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-2")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if !stored.IsMerged() || stored.MergeOverride == nil || stored.MergeOverride.ActorID != "u4" || stored.MergeOverride.Reason != "incident" {
+		t.Errorf("expected recorded override by u4, got status %s, override %+v", stored.Status, stored.MergeOverride)
+	}
+}
+
+func TestMergePR_TakesOverrideActorFromContext(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	approvals := 2
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{RequiredApprovals: &approvals}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	for _, id := range []string{"pr-1", "pr-2"} {
+		if _, err := service.CreatePR(ctx, id, "Feature", "u1"); err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+	}
+
+	admin := core.WithPrincipal(ctx, core.Principal{TokenID: 1, Role: core.RoleAdmin})
+	opts := core.MergeOptions{Force: true, Reason: "incident"}
+	if _, err := service.MergePRWithOptions(admin, "pr-1", opts); !errors.Is(err, core.ErrInvalidOverride) {
+		t.Errorf("expected ErrInvalidOverride for admin token without actor, got %v", err)
+	}
+	opts.ActorID = "u4"
+	if _, err := service.MergePRWithOptions(admin, "pr-1", opts); !errors.Is(err, core.ErrInvalidOverride) {
+		t.Errorf("expected ErrInvalidOverride for actor only in the body, got %v", err)
+	}
+
+	// пользователь токена важнее заявленного в теле и в контексте
+	bound := core.WithActor(core.WithPrincipal(ctx, core.Principal{TokenID: 2, Role: core.RoleAdmin, UserID: "u2"}), "u3")
+	if _, err := service.MergePRWithOptions(bound, "pr-1", opts); !errors.Is(err, core.ErrInvalidOverride) {
+		t.Errorf("expected ErrInvalidOverride for conflicting actor_id, got %v", err)
+	}
+	opts.ActorID = ""
+	if _, err := service.MergePRWithOptions(bound, "pr-1", opts); err != nil {
+		t.Fatalf("failed to force merge: %v", err)
+	}
+
+	opts.ActorID = "u3"
+	if _, err := service.MergePRWithOptions(core.WithActor(admin, "u3"), "pr-2", opts); err != nil {
+		t.Fatalf("failed to force merge with matching actor_id: %v", err)
+	}
+
+	for id, actorID := range map[string]string{"pr-1": "u2", "pr-2": "u3"} {
+		stored, err := storage.PR.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("failed to get PR: %v", err)
+		}
+		if stored.MergeOverride == nil || stored.MergeOverride.ActorID != actorID {
+			t.Errorf("expected override of %s by %s, got %+v", id, actorID, stored.MergeOverride)
+		}
+	}
+}
+
+func TestCreatePR_UsesFallbackTeams(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2")
+	ctx := context.Background()
+
+	for _, team := range []struct {
+		name    string
+		members []string
+	}{{"platform", []string{"p1"}}, {"infra", []string{"i1", "i2"}}} {
+		users := make([]core.User, len(team.members))
+		for i, id := range team.members {
+			users[i] = core.User{ID: id, Username: id, TeamName: team.name, IsActive: true}
+		}
+		if err := service.CreateTeam(ctx, team.name, users); err != nil {
+			t.Fatalf("failed to create team %s: %v", team.name, err)
+		}
+	}
+
+	for _, fallbackTeams := range [][]string{{"backend"}, {"unknown"}, {"platform", "platform"}} {
+		if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{FallbackTeams: &fallbackTeams}); !errors.Is(err, core.ErrInvalidSettings) {
+			t.Errorf("expected ErrInvalidSettings for fallback teams %v, got %v", fallbackTeams, err)
+		}
+	}
+
+	required := 3
+	fallbackTeams := []string{"platform", "infra"}
+	patch := core.TeamSettingsPatch{RequiredReviewers: &required, FallbackTeams: &fallbackTeams}
+	if _, err := service.UpdateTeamSettings(ctx, "backend", patch); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if len(pr.ReviewersIDs) != 3 || pr.MissingReviewers() != 0 {
+		t.Fatalf("expected 3 reviewers, got %v", pr.ReviewersIDs)
+	}
+
+	expected := map[string]string{"u2": "", "p1": "platform"}
+	for _, reviewerID := range pr.ReviewersIDs {
+		fallbackTeam, ok := expected[reviewerID]
+		if !ok {
+			fallbackTeam = "infra"
+		}
+		if got := pr.Review(reviewerID).FallbackTeam; got != fallbackTeam {
+			t.Errorf("expected reviewer %s from fallback team %q, got %q", reviewerID, fallbackTeam, got)
+		}
+	}
+
+	// в своей команде замены нет, поэтому берётся оставшийся участник infra
+	if _, err := service.SetUserActive(ctx, "u1", false); err != nil {
+		t.Fatalf("failed to deactivate author: %v", err)
+	}
+	updated, newReviewerID, err := service.ReassignReviewer(ctx, "pr-1", "u2")
+	if err != nil {
+		t.Fatalf("expected fallback replacement, got %v", err)
+	}
+	if updated.Review(newReviewerID).FallbackTeam != "infra" {
+		t.Errorf("expected replacement %s from infra, got %q", newReviewerID, updated.Review(newReviewerID).FallbackTeam)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if stored.Review("p1").FallbackTeam != "platform" || stored.Review(newReviewerID).FallbackTeam != "infra" {
+		t.Errorf("expected fallback teams to be stored, got %+v", stored.Reviews)
+	}
+}
+
+func TestCreatePR_AssignsCodeOwner(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3")
+	ctx := context.Background()
+
+	dba := []core.User{{ID: "d1", Username: "d1", TeamName: "dba", IsActive: true}}
+	if err := service.CreateTeam(ctx, "dba", dba); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	invalid := []core.OwnershipRule{{Pattern: "*.sql", Users: []string{"ghost"}}}
+	if _, err := service.SetOwnershipRules(ctx, "backend", invalid); !errors.Is(err, core.ErrInvalidCodeOwners) {
+		t.Fatalf("expected ErrInvalidCodeOwners for unknown owner, got %v", err)
+	}
+
+	rules := []core.OwnershipRule{
+		{Pattern: "*.sql", Users: []string{"u3"}},
+		// последнее подходящее правило переопределяет предыдущие
+		{Pattern: "migrations/", Teams: []string{"dba"}},
+	}
+	if _, err := service.SetOwnershipRules(ctx, "backend", rules); err != nil {
+		t.Fatalf("failed to set ownership rules: %v", err)
+	}
+
+	pr, err := service.CreatePRWithOptions(ctx, "pr-1", "Migration", "u1", core.CreatePROptions{
+		ChangedFiles: []string{"internal/db/migrations/001.up.sql", "internal/db/pr.go"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if !pr.HasReviewer("d1") || pr.Review("d1").OwnerPattern != "migrations/" {
+		t.Fatalf("expected code owner d1 by migrations/, got %+v", pr.Reviews)
+	}
+	if len(pr.ReviewersIDs) != 2 {
+		t.Errorf("expected owner plus one team reviewer, got %v", pr.ReviewersIDs)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if stored.Review("d1").OwnerPattern != "migrations/" {
+		t.Errorf("expected owner pattern to be stored, got %+v", stored.Reviews)
+	}
+
+	// без совпадений владельцев ревьюверы выбираются как обычно
+	pr, err = service.CreatePRWithOptions(ctx, "pr-2", "Docs", "u1", core.CreatePROptions{ChangedFiles: []string{"README.md"}})
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if pr.HasReviewer("d1") || len(pr.ReviewersIDs) != 2 {
+		t.Errorf("expected two backend reviewers, got %v", pr.ReviewersIDs)
+	}
+}
+
+func TestCreatePR_SkipsUnavailableUsers(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+	now := time.Now()
+
+	invalid := []core.Unavailability{{From: now, To: now.Add(-time.Hour)}}
+	if _, err := service.SetUserUnavailability(ctx, "u2", invalid); !errors.Is(err, core.ErrInvalidUnavailability) {
+		t.Fatalf("expected ErrInvalidUnavailability, got %v", err)
+	}
+
+	vacation := []core.Unavailability{{From: now.Add(-24 * time.Hour), To: now.Add(24 * time.Hour)}}
+	user, err := service.SetUserUnavailability(ctx, "u2", vacation)
+	if err != nil {
+		t.Fatalf("failed to set unavailability: %v", err)
+	}
+	if user.CanBeReviewer() {
+		t.Fatal("expected user on vacation to be unavailable")
+	}
+
+	// будущий отпуск на текущие назначения не влияет
+	upcoming := []core.Unavailability{{From: now.Add(24 * time.Hour), To: now.Add(48 * time.Hour)}}
+	if _, err := service.SetUserUnavailability(ctx, "u3", upcoming); err != nil {
+		t.Fatalf("failed to set unavailability: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		pr, err := service.CreatePR(ctx, fmt.Sprintf("pr-%d", i), "Feature", "u1")
+		if err != nil {
+			t.Fatalf("failed to create PR: %v", err)
+		}
+		if pr.HasReviewer("u2") {
+			t.Fatalf("user on vacation was assigned to %s", pr.ID)
+		}
+		if !pr.HasReviewer("u3") || !pr.HasReviewer("u4") {
+			t.Fatalf("expected u3 and u4, got %v", pr.ReviewersIDs)
+		}
+	}
+
+	if _, err := service.SetUserUnavailability(ctx, "u2", nil); err != nil {
+		t.Fatalf("failed to clear unavailability: %v", err)
+	}
+	user, err = service.SetUserActive(ctx, "u2", true)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if !user.CanBeReviewer() {
+		t.Error("expected user to be available after clearing unavailability")
+	}
+}
+
+func TestSetUserActive_ReassignsOpenPRs(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	first, second := pr.ReviewersIDs[0], pr.ReviewersIDs[1]
+
+	// без флага и настройки команды ревьювер остаётся в PR
+	_, reassignments, err := service.SetUserActiveWithOptions(ctx, first, false, core.SetUserActiveOptions{})
+	if err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if len(reassignments) != 0 {
+		t.Fatalf("expected no reassignments, got %+v", reassignments)
+	}
+
+	autoReassign := true
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{AutoReassign: &autoReassign}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	_, reassignments, err = service.SetUserActiveWithOptions(ctx, second, false, core.SetUserActiveOptions{})
+	if err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if len(reassignments) != 1 || reassignments[0].Err != nil || reassignments[0].OldReviewerID != second {
+		t.Fatalf("expected one successful reassignment, got %+v", reassignments)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if stored.HasReviewer(second) || !stored.HasReviewer(reassignments[0].NewReviewerID) {
+		t.Errorf("expected %s to be replaced by %s, got %v", second, reassignments[0].NewReviewerID, stored.ReviewersIDs)
+	}
+
+	solo := []core.User{
+		{ID: "s1", Username: "s1", TeamName: "solo", IsActive: false},
+		{ID: "s2", Username: "s2", TeamName: "solo", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "solo", solo); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	if _, err := service.CreatePR(ctx, "pr-2", "Fix", "s1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	_, reassignments, err = service.SetUserActiveWithOptions(ctx, "s2", false, core.SetUserActiveOptions{Reassign: true})
+	if err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if len(reassignments) != 1 || !errors.Is(reassignments[0].Err, core.ErrNoCandidate) {
+		t.Fatalf("expected failed reassignment with ErrNoCandidate, got %+v", reassignments)
+	}
+	if stored, _ := storage.PR.GetByID(ctx, "pr-2"); !stored.HasReviewer("s2") {
+		t.Errorf("expected s2 to stay assigned after failed reassignment, got %v", stored.ReviewersIDs)
+	}
+}
+
+func TestHandleOverdueReviews_EscalatesOnce(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3")
+	ctx := context.Background()
+
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	// без SLA ревью не просрочены
+	overdue, err := service.GetOverduePRs(ctx)
+	if err != nil {
+		t.Fatalf("failed to get overdue PRs: %v", err)
+	}
+	if len(overdue) != 0 {
+		t.Fatalf("expected no overdue PRs without SLA, got %+v", overdue)
+	}
+
+	sla := time.Nanosecond
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{ReviewSLA: &sla}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	overdue, err = service.GetOverduePRs(ctx)
+	if err != nil {
+		t.Fatalf("failed to get overdue PRs: %v", err)
+	}
+	if len(overdue) != 1 || len(overdue[0].Reviews) != 2 {
+		t.Fatalf("expected one PR with two overdue reviews, got %+v", overdue)
+	}
+
+	report, err := service.HandleOverdueReviews(ctx, core.SLAActionEscalate)
+	if err != nil {
+		t.Fatalf("failed to handle overdue reviews: %v", err)
+	}
+	if report.Escalated != 2 || report.Reassigned != 0 {
+		t.Fatalf("expected two escalations, got %+v", report)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	for _, review := range stored.Reviews {
+		if review.EscalatedAt == nil {
+			t.Errorf("expected review of %s to be escalated", review.ReviewerID)
+		}
+	}
+
+	// повторный проход не эскалирует те же ревью
+	report, err = service.HandleOverdueReviews(ctx, core.SLAActionEscalate)
+	if err != nil {
+		t.Fatalf("failed to handle overdue reviews: %v", err)
+	}
+	if report.Escalated != 0 {
+		t.Errorf("expected no repeated escalations, got %+v", report)
+	}
+}
+
+func TestHandleOverdueReviews_Reassigns(t *testing.T) {
+	service, storage := newTestService(t, "u1", "u2", "u3", "u4")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	// автор не должен стать заменой
+	if _, err := service.SetUserActive(ctx, "u1", false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+
+	sla := time.Nanosecond
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{ReviewSLA: &sla}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	report, err := service.HandleOverdueReviews(ctx, core.SLAActionReassign)
+	if err != nil {
+		t.Fatalf("failed to handle overdue reviews: %v", err)
+	}
+	if report.Reassigned != 2 || report.Escalated != 0 {
+		t.Fatalf("expected two reassignments, got %+v", report)
+	}
+
+	stored, err := storage.PR.GetByID(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+	if stored.HasReviewer(pr.ReviewersIDs[1]) || len(stored.ReviewersIDs) != 2 {
+		t.Errorf("expected %s to be replaced, got %v", pr.ReviewersIDs[1], stored.ReviewersIDs)
+	}
+
+	// без кандидатов на замену ревью эскалируется
+	solo := []core.User{
+		{ID: "s1", Username: "s1", TeamName: "solo", IsActive: true},
+		{ID: "s2", Username: "s2", TeamName: "solo", IsActive: true},
+	}
+	if err := service.CreateTeamWithSettings(ctx, "solo", solo, core.TeamSettings{ReviewSLA: sla}); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	if _, err := service.CreatePR(ctx, "pr-2", "Fix", "s1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.SetUserActive(ctx, "s1", false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+
+	report, err = service.HandleOverdueReviews(ctx, core.SLAActionReassign)
+	if err != nil {
+		t.Fatalf("failed to handle overdue reviews: %v", err)
+	}
+	if report.Escalated != 1 {
+		t.Fatalf("expected escalation of s2, got %+v", report)
+	}
+	if stored, _ := storage.PR.GetByID(ctx, "pr-2"); stored.Review("s2").EscalatedAt == nil {
+		t.Errorf("expected review of s2 to be escalated")
+	}
+
+	if _, err := service.HandleOverdueReviews(ctx, core.SLAAction("ignore")); !errors.Is(err, core.ErrInvalidSettings) {
+		t.Errorf("expected ErrInvalidSettings for unknown action, got %v", err)
+	}
+}
+
+func TestAssignmentHistory_RecordsReasons(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAssignmentHistory(storage.Assignment))
+	ctx := core.WithActor(context.Background(), "lead")
+
+	members := []core.User{
+		{ID: "u1", Username: "u1", TeamName: "backend", IsActive: true},
+		{ID: "u2", Username: "u2", TeamName: "backend", IsActive: true},
+		{ID: "u3", Username: "u3", TeamName: "backend", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "backend", members); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	// u2 и u3 уже назначены, а автор исключён, поэтому u2 снимается без замены
+	if _, _, err := service.DeactivateTeamUsers(ctx, "backend", []string{"u2"}); err != nil {
+		t.Fatalf("failed to deactivate users: %v", err)
+	}
+
+	history, err := service.GetPRHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected two assignments, got %+v", history)
+	}
+	for _, assignment := range history {
+		if assignment.Reason != core.AssignmentReasonInitial || assignment.ActorID != "lead" {
+			t.Errorf("expected initial assignment by lead, got %+v", assignment)
+		}
+		if closed := assignment.UnassignedAt != nil; closed != (assignment.ReviewerID == "u2") {
+			t.Errorf("expected only u2 to be unassigned, got %+v", assignment)
+		}
+	}
+
+	if _, err := service.SetUserActive(ctx, "u2", true); err != nil {
+		t.Fatalf("failed to activate user: %v", err)
+	}
+	_, reassignments, err := service.SetUserActiveWithOptions(ctx, "u3", false, core.SetUserActiveOptions{Reassign: true})
+	if err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if len(reassignments) != 1 || reassignments[0].Err != nil {
+		t.Fatalf("expected one reassignment, got %+v", reassignments)
+	}
+
+	history, err = service.GetPRHistory(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	last := history[len(history)-1]
+	if last.Reason != core.AssignmentReasonDeactivation || last.ReplacedReviewerID != "u3" ||
+		last.ReviewerID != reassignments[0].NewReviewerID || last.UnassignedAt != nil {
+		t.Errorf("expected open deactivation assignment replacing u3, got %+v", last)
+	}
+
+	if _, err := service.GetPRHistory(ctx, "unknown"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown PR, got %v", err)
+	}
+}
+
+type recordingMetrics struct {
+	created       []int
+	merged        int
+	reassignments map[core.AssignmentReason]int
+	noCandidate   int
+}
+
+func (m *recordingMetrics) PRCreated(reviewers int) { m.created = append(m.created, reviewers) }
+func (m *recordingMetrics) PRMerged()               { m.merged++ }
+func (m *recordingMetrics) NoCandidate()            { m.noCandidate++ }
+
+func (m *recordingMetrics) ReviewerReassigned(reason core.AssignmentReason) {
+	m.reassignments[reason]++
+}
+
+func TestMetrics_CountsDomainOperations(t *testing.T) {
+	storage := memory.New()
+	metrics := &recordingMetrics{reassignments: make(map[core.AssignmentReason]int)}
+	service := core.NewService(storage.Team, storage.User, storage.PR, core.WithTransactor(storage), core.WithMetrics(metrics))
+	ctx := context.Background()
+
+	teams := map[string][]string{"backend": {"u1", "u2", "u3", "u4"}, "solo": {"s1", "s2"}}
+	for name, members := range teams {
+		users := make([]core.User, len(members))
+		for i, id := range members {
+			users[i] = core.User{ID: id, Username: id, TeamName: name, IsActive: true}
+		}
+		if err := service.CreateTeam(ctx, name, users); err != nil {
+			t.Fatalf("failed to create team: %v", err)
+		}
+	}
+
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := service.MergePR(ctx, "pr-1"); err != nil {
+			t.Fatalf("failed to merge PR: %v", err)
+		}
+	}
+
+	pr, err := service.CreatePR(ctx, "pr-2", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(ctx, "pr-2", pr.ReviewersIDs[0]); err != nil {
+		t.Fatalf("failed to reassign reviewer: %v", err)
+	}
+
+	// у s2 нет замены: автор s1 неактивен, других участников нет
+	if _, err := service.CreatePR(ctx, "pr-3", "Feature", "s1"); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.SetUserActive(ctx, "s1", false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(ctx, "pr-3", "s2"); !errors.Is(err, core.ErrNoCandidate) {
+		t.Fatalf("expected ErrNoCandidate, got %v", err)
+	}
+
+	if fmt.Sprint(metrics.created) != "[2 2 1]" {
+		t.Errorf("expected PRs created with 2, 2 and 1 reviewers, got %v", metrics.created)
+	}
+	if metrics.merged != 1 {
+		t.Errorf("expected repeated merge to be counted once, got %d", metrics.merged)
+	}
+	if metrics.reassignments[core.AssignmentReasonReassign] != 1 || len(metrics.reassignments) != 1 {
+		t.Errorf("expected one manual reassignment, got %v", metrics.reassignments)
+	}
+	if metrics.noCandidate != 1 {
+		t.Errorf("expected one missing candidate, got %d", metrics.noCandidate)
+	}
+}
+
+func TestAuthorization_LimitsUserRole(t *testing.T) {
+	service, _ := newTestService(t, "u1", "u2", "u3", "u4", "u5")
+	ctx := context.Background()
+
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	reviewerID := pr.ReviewersIDs[0]
+	var outsiderID string
+	for _, id := range []string{"u2", "u3", "u4", "u5"} {
+		if !pr.HasReviewer(id) {
+			outsiderID = id
+			break
+		}
+	}
+	asUser := func(userID string) context.Context {
+		return core.WithPrincipal(ctx, core.Principal{Role: core.RoleUser, UserID: userID})
+	}
+
+	if _, err := service.SetUserActive(asUser("u1"), "u2", false); !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for user management, got %v", err)
+	}
+	if _, err := service.GetPR(asUser(outsiderID), "pr-1"); !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for PR read by outsider, got %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(asUser(outsiderID), "pr-1", reviewerID); !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for reassign by outsider, got %v", err)
+	}
+	if _, err := service.SubmitReview(asUser("u1"), "pr-1", reviewerID, core.ReviewStateApproved); !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for review on behalf of another user, got %v", err)
+	}
+	_, err = service.MergePRWithOptions(asUser("u1"), "pr-1", core.MergeOptions{Force: true, ActorID: "u1", Reason: "hotfix"})
+	if !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for forced merge by user, got %v", err)
+	}
+
+	if _, err := service.GetPR(asUser(reviewerID), "pr-1"); err != nil {
+		t.Errorf("expected reviewer to read PR, got %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(asUser(reviewerID), "pr-1", reviewerID); err != nil {
+		t.Errorf("expected reviewer to reassign themselves, got %v", err)
+	}
+
+	// администратор и вызовы без токена, например SLA, ролями не ограничены
+	admin := core.WithPrincipal(ctx, core.Principal{Role: core.RoleAdmin})
+	if _, err := service.SetUserActive(admin, "u5", false); err != nil {
+		t.Errorf("expected admin to manage users, got %v", err)
+	}
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Errorf("expected internal call to be allowed, got %v", err)
+	}
+}
+
+type failingAudit struct {
+	core.AuditStore
+}
+
+func (failingAudit) Append(context.Context, *core.AuditRecord) error {
+	return errors.New("audit is unavailable")
+}
+
+func TestAuditLog_RecordsMutations(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(storage.Audit))
+	ctx := core.WithActor(context.Background(), "lead")
+
+	members := []core.User{
+		{ID: "u1", Username: "u1", TeamName: "backend", IsActive: true},
+		{ID: "u2", Username: "u2", TeamName: "backend", IsActive: true},
+		{ID: "u3", Username: "u3", TeamName: "backend", IsActive: true},
+		{ID: "u4", Username: "u4", TeamName: "backend", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "backend", members); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, _, err := service.ReassignReviewer(ctx, "pr-1", pr.ReviewersIDs[0]); err != nil {
+		t.Fatalf("failed to reassign reviewer: %v", err)
+	}
+	if _, err := service.SetUserActive(ctx, "u4", false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if _, err := service.MergePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to merge PR: %v", err)
+	}
+
+	// неуспешные и повторные операции в журнал не попадают
+	if _, err := service.CreatePR(ctx, "pr-1", "Feature", "u1"); !errors.Is(err, core.ErrPRExists) {
+		t.Fatalf("expected ErrPRExists, got %v", err)
+	}
+	if _, err := service.MergePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to merge PR again: %v", err)
+	}
+
+	page, err := service.GetAuditLog(ctx, core.AuditFilter{})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	expected := []core.AuditOperation{
+		core.AuditOperationMergePR, core.AuditOperationSetUserActive, core.AuditOperationReassignReviewer,
+		core.AuditOperationCreatePR, core.AuditOperationCreateTeam,
+	}
+	if len(page.Records) != len(expected) || page.NextBeforeID != 0 {
+		t.Fatalf("expected %d records on one page, got %+v", len(expected), page)
+	}
+	for i, record := range page.Records {
+		if record.Operation != expected[i] || record.ActorID != "lead" {
+			t.Errorf("expected %s by lead at %d, got %+v", expected[i], i, record)
+		}
+	}
+	var deactivation struct {
+		TeamName  string `json:"team_name"`
+		WasActive bool   `json:"was_active"`
+	}
+	if err := json.Unmarshal(page.Records[1].Result, &deactivation); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if page.Records[1].EntityID != "u4" || deactivation.TeamName != "backend" || !deactivation.WasActive {
+		t.Errorf("unexpected deactivation record: %+v, result %s", page.Records[1], page.Records[1].Result)
+	}
+
+	page, err = service.GetAuditLog(ctx, core.AuditFilter{EntityID: "pr-1", Limit: 2})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	if len(page.Records) != 2 || page.Records[0].Operation != core.AuditOperationMergePR || page.NextBeforeID == 0 {
+		t.Fatalf("expected first page of PR records, got %+v", page)
+	}
+	page, err = service.GetAuditLog(ctx, core.AuditFilter{EntityID: "pr-1", Limit: 2, BeforeID: page.NextBeforeID})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Operation != core.AuditOperationCreatePR || page.NextBeforeID != 0 {
+		t.Fatalf("expected last page with PR creation, got %+v", page)
+	}
+
+	if _, err := service.GetAuditLog(ctx, core.AuditFilter{Operation: "delete_team"}); !errors.Is(err, core.ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter for unknown operation, got %v", err)
+	}
+	asUser := core.WithPrincipal(ctx, core.Principal{Role: core.RoleUser, UserID: "u1"})
+	if _, err := service.GetAuditLog(asUser, core.AuditFilter{}); !errors.Is(err, core.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for user, got %v", err)
+	}
+
+	// запись пишется в транзакции изменения: без неё изменение откатывается
+	failing := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(failingAudit{}))
+	if _, err := failing.SetUserActive(ctx, "u4", true); err == nil {
+		t.Fatalf("expected audit failure")
+	}
+	user, err := storage.User.GetByID(ctx, "u4")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if user.IsActive {
+		t.Errorf("expected activation to be rolled back")
+	}
+}
+
+func TestAuditLog_RecordsSettingsReviewsAndTokens(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(storage.Audit), core.WithTokens(storage.Token))
+	ctx := core.WithActor(context.Background(), "lead")
+
+	members := []core.User{
+		{ID: "u1", Username: "u1", TeamName: "backend", IsActive: true},
+		{ID: "u2", Username: "u2", TeamName: "backend", IsActive: true},
+		{ID: "u3", Username: "u3", TeamName: "backend", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "backend", members); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	required := 1
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{RequiredReviewers: &required}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	rules := []core.OwnershipRule{{Pattern: "api/**", Users: []string{"u2"}}}
+	if _, err := service.SetOwnershipRules(ctx, "backend", rules); err != nil {
+		t.Fatalf("failed to set ownership rules: %v", err)
+	}
+	from := time.Now().Add(24 * time.Hour)
+	windows := []core.Unavailability{{From: from, To: from.Add(48 * time.Hour)}}
+	if _, err := service.SetUserUnavailability(ctx, "u3", windows); err != nil {
+		t.Fatalf("failed to set unavailability: %v", err)
+	}
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	if _, err := service.SubmitReview(ctx, "pr-1", pr.ReviewersIDs[0], core.ReviewStateApproved); err != nil {
+		t.Fatalf("failed to submit review: %v", err)
+	}
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to close PR: %v", err)
+	}
+	// повторное закрытие ничего не меняет и в журнал не попадает
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to close PR again: %v", err)
+	}
+	if _, _, err := service.ReopenPR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to reopen PR: %v", err)
+	}
+	token, secret, err := service.CreateToken(ctx, "ci", core.RoleAdmin, "")
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	if err := service.RevokeToken(ctx, token.ID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	page, err := service.GetAuditLog(ctx, core.AuditFilter{})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	expected := []core.AuditOperation{
+		core.AuditOperationRevokeToken, core.AuditOperationCreateToken, core.AuditOperationReopenPR,
+		core.AuditOperationClosePR, core.AuditOperationSubmitReview, core.AuditOperationCreatePR,
+		core.AuditOperationSetUserUnavailability, core.AuditOperationSetOwnershipRules,
+		core.AuditOperationUpdateTeamSettings, core.AuditOperationCreateTeam,
+	}
+	if len(page.Records) != len(expected) {
+		t.Fatalf("expected %d records, got %+v", len(expected), page.Records)
+	}
+	for i, record := range page.Records {
+		if record.Operation != expected[i] || record.ActorID != "lead" {
+			t.Errorf("expected %s by lead at %d, got %+v", expected[i], i, record)
+		}
+	}
+	tokenID := strconv.FormatInt(token.ID, 10)
+	if page.Records[0].EntityID != tokenID || page.Records[1].EntityID != tokenID {
+		t.Errorf("expected token records for %s, got %+v", tokenID, page.Records[:2])
+	}
+	if strings.Contains(string(page.Records[1].Payload), secret) || strings.Contains(string(page.Records[1].Payload), token.Hash) {
+		t.Errorf("expected token value to stay out of the audit log, got %s", page.Records[1].Payload)
+	}
+	if page.Records[8].EntityID != "backend" || !strings.Contains(string(page.Records[8].Payload), `"required_reviewers":1`) {
+		t.Errorf("unexpected settings record: %+v, payload %s", page.Records[8], page.Records[8].Payload)
+	}
+
+	// запись пишется в транзакции изменения: без неё изменение откатывается
+	failing := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(failingAudit{}))
+	if _, err := failing.ClosePR(ctx, "pr-1"); err == nil {
+		t.Fatalf("expected audit failure")
+	}
+	if stored, err := storage.PR.GetByID(ctx, "pr-1"); err != nil || stored.IsClosed() {
+		t.Errorf("expected closing to be rolled back, got %+v, %v", stored, err)
+	}
+}
+
+func TestAuditLog_RecordsAutomaticReassignments(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(storage.Audit))
+	ctx := core.WithActor(context.Background(), "lead")
+
+	members := make([]core.User, 0, 6)
+	for _, id := range []string{"u1", "u2", "u3", "u4", "u5", "u6"} {
+		members = append(members, core.User{ID: id, Username: id, TeamName: "backend", IsActive: true})
+	}
+	if err := service.CreateTeam(ctx, "backend", members); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	// замена при деактивации
+	if _, _, err := service.SetUserActiveWithOptions(ctx, pr.ReviewersIDs[0], false, core.SetUserActiveOptions{Reassign: true}); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	// замена при повторном открытии
+	if _, err := service.ClosePR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to close PR: %v", err)
+	}
+	if _, err := service.SetUserActive(ctx, pr.ReviewersIDs[1], false); err != nil {
+		t.Fatalf("failed to deactivate user: %v", err)
+	}
+	if _, _, err := service.ReopenPR(ctx, "pr-1"); err != nil {
+		t.Fatalf("failed to reopen PR: %v", err)
+	}
+	// замены по SLA выполняет сам сервис, без пользователя операции
+	sla := time.Nanosecond
+	if _, err := service.UpdateTeamSettings(ctx, "backend", core.TeamSettingsPatch{ReviewSLA: &sla}); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	if _, err := service.HandleOverdueReviews(context.Background(), core.SLAActionReassign); err != nil {
+		t.Fatalf("failed to handle overdue reviews: %v", err)
+	}
+
+	page, err := service.GetAuditLog(ctx, core.AuditFilter{Operation: core.AuditOperationReassignReviewer})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	actors := make(map[core.AssignmentReason][]string)
+	for _, record := range page.Records {
+		var payload struct {
+			Reason core.AssignmentReason `json:"reason"`
+		}
+		if err := json.Unmarshal(record.Payload, &payload); err != nil {
+			t.Fatalf("failed to unmarshal payload: %v", err)
+		}
+		actors[payload.Reason] = append(actors[payload.Reason], record.ActorID)
+	}
+	if fmt.Sprint(actors[core.AssignmentReasonDeactivation]) != "[lead]" || fmt.Sprint(actors[core.AssignmentReasonReopen]) != "[lead]" {
+		t.Errorf("expected deactivation and reopen replacements by lead, got %v", actors)
+	}
+	if fmt.Sprint(actors[core.AssignmentReasonSLA]) != "["+core.SystemActorID+" "+core.SystemActorID+"]" {
+		t.Errorf("expected two SLA replacements by %s, got %v", core.SystemActorID, actors)
+	}
+}
+
+func TestAuditLog_RecordsTeamDeactivation(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(storage.Audit))
+	ctx := core.WithActor(context.Background(), "lead")
+
+	members := []core.User{
+		{ID: "u1", Username: "u1", TeamName: "backend", IsActive: true},
+		{ID: "u2", Username: "u2", TeamName: "backend", IsActive: true},
+		{ID: "u3", Username: "u3", TeamName: "backend", IsActive: true},
+		{ID: "u4", Username: "u4", TeamName: "backend", IsActive: true},
+	}
+	if err := service.CreateTeam(ctx, "backend", members); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	pr, err := service.CreatePR(ctx, "pr-1", "Feature", "u1")
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+	reviewerID := pr.ReviewersIDs[0]
+	idle := "u4"
+	for _, id := range []string{"u2", "u3", "u4"} {
+		if !pr.HasReviewer(id) {
+			idle = id
+		}
+	}
+
+	// записи пишутся в транзакции деактивации: без них деактивация откатывается
+	failing := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithTransactor(storage), core.WithAudit(failingAudit{}))
+	if _, _, err := failing.DeactivateTeamUsers(ctx, "backend", []string{reviewerID, idle}); err == nil {
+		t.Fatalf("expected audit failure")
+	}
+	if user, err := storage.User.GetByID(ctx, reviewerID); err != nil || !user.IsActive {
+		t.Fatalf("expected deactivation to be rolled back, got %+v, %v", user, err)
+	}
+
+	// запись на каждого пользователя, с заменами только в его PR
+	if _, _, err := service.DeactivateTeamUsers(ctx, "backend", []string{reviewerID, idle}); err != nil {
+		t.Fatalf("failed to deactivate users: %v", err)
+	}
+	page, err := service.GetAuditLog(ctx, core.AuditFilter{Operation: core.AuditOperationDeactivateUser})
+	if err != nil {
+		t.Fatalf("failed to get audit log: %v", err)
+	}
+	if len(page.Records) != 2 {
+		t.Fatalf("expected a record per deactivated user, got %+v", page.Records)
+	}
+	replaced := make(map[string]int)
+	for _, record := range page.Records {
+		if record.ActorID != "lead" {
+			t.Errorf("expected record by lead, got %+v", record)
+		}
+		var result struct {
+			Reassignments []struct {
+				PullRequestID string `json:"pull_request_id"`
+			} `json:"reassignments"`
+		}
+		if err := json.Unmarshal(record.Result, &result); err != nil {
+			t.Fatalf("failed to unmarshal result: %v", err)
+		}
+		replaced[record.EntityID] = len(result.Reassignments)
+	}
+	if len(replaced) != 2 || replaced[reviewerID] != 1 || replaced[idle] != 0 {
+		t.Errorf("expected one reassignment for %s and none for %s, got %v", reviewerID, idle, replaced)
+	}
+}
+
+func TestIdempotentRequest_ReplaysStoredResponse(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithIdempotency(storage.Idempotency, time.Hour, time.Minute))
+	ctx := context.Background()
+	body := []byte(`{"pull_request_id":"pr-1"}`)
+
+	stored, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body)
+	if err != nil || stored != nil {
+		t.Fatalf("expected key to be reserved, got %+v, %v", stored, err)
+	}
+	// повтор до завершения первого запроса
+	if _, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body); !errors.Is(err, core.ErrIdempotencyInProgress) {
+		t.Errorf("expected ErrIdempotencyInProgress, got %v", err)
+	}
+
+	response := core.IdempotentResponse{StatusCode: 201, Body: []byte(`{"pr":{}}`)}
+	if err := service.CompleteIdempotentRequest(ctx, "key-1", response); err != nil {
+		t.Fatalf("failed to complete request: %v", err)
+	}
+	stored, err = service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body)
+	if err != nil || stored == nil || stored.StatusCode != 201 || string(stored.Body) != `{"pr":{}}` {
+		t.Fatalf("expected stored response, got %+v, %v", stored, err)
+	}
+
+	if _, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", []byte(`{}`)); !errors.Is(err, core.ErrIdempotencyConflict) {
+		t.Errorf("expected ErrIdempotencyConflict for another body, got %v", err)
+	}
+	if _, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/merge", body); !errors.Is(err, core.ErrIdempotencyConflict) {
+		t.Errorf("expected ErrIdempotencyConflict for another route, got %v", err)
+	}
+	if _, err := service.BeginIdempotentRequest(ctx, "key with spaces", "POST /pullRequest/create", body); !errors.Is(err, core.ErrInvalidIdempotencyKey) {
+		t.Errorf("expected ErrInvalidIdempotencyKey, got %v", err)
+	}
+
+	// ключи разных токенов не пересекаются
+	other := core.WithPrincipal(ctx, core.Principal{TokenID: 7, Role: core.RoleAdmin})
+	if stored, err := service.BeginIdempotentRequest(other, "key-1", "POST /pullRequest/create", body); err != nil || stored != nil {
+		t.Errorf("expected separate key for another token, got %+v, %v", stored, err)
+	}
+
+	// освобождённый ключ можно использовать заново
+	if err := service.AbortIdempotentRequest(other, "key-1"); err != nil {
+		t.Fatalf("failed to abort request: %v", err)
+	}
+	if stored, err := service.BeginIdempotentRequest(other, "key-1", "POST /pullRequest/merge", body); err != nil || stored != nil {
+		t.Errorf("expected released key to be reserved again, got %+v, %v", stored, err)
+	}
+
+	// просроченный ключ заменяется новым
+	expiring := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithIdempotency(storage.Idempotency, time.Millisecond, time.Minute))
+	if _, err := expiring.BeginIdempotentRequest(ctx, "key-2", "POST /pullRequest/create", body); err != nil {
+		t.Fatalf("failed to reserve key: %v", err)
+	}
+	if err := expiring.CompleteIdempotentRequest(ctx, "key-2", response); err != nil {
+		t.Fatalf("failed to complete request: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if stored, err := expiring.BeginIdempotentRequest(ctx, "key-2", "POST /pullRequest/create", []byte(`{}`)); err != nil || stored != nil {
+		t.Errorf("expected expired key to be reserved again, got %+v, %v", stored, err)
+	}
+}
+
+func TestIdempotentRequest_ReleasesAbandonedKey(t *testing.T) {
+	storage := memory.New()
+	service := core.NewService(storage.Team, storage.User, storage.PR,
+		core.WithIdempotency(storage.Idempotency, time.Hour, 5*time.Millisecond))
+	ctx := context.Background()
+	body := []byte(`{"pull_request_id":"pr-1"}`)
+
+	// первый запрос упал, не сохранив ответ и не освободив ключ
+	if _, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body); err != nil {
+		t.Fatalf("failed to reserve key: %v", err)
+	}
+	if _, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body); !errors.Is(err, core.ErrIdempotencyInProgress) {
+		t.Fatalf("expected ErrIdempotencyInProgress within lease, got %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	stored, err := service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body)
+	if err != nil || stored != nil {
+		t.Fatalf("expected abandoned key to be reserved again, got %+v, %v", stored, err)
+	}
+
+	// ответ хранится TTL, а не аренду
+	response := core.IdempotentResponse{StatusCode: 201, Body: []byte(`{"pr":{}}`)}
+	if err := service.CompleteIdempotentRequest(ctx, "key-1", response); err != nil {
+		t.Fatalf("failed to complete request: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	stored, err = service.BeginIdempotentRequest(ctx, "key-1", "POST /pullRequest/create", body)
+	if err != nil || stored == nil || stored.StatusCode != 201 {
+		t.Errorf("expected stored response after lease, got %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import "time"
+
+// SLAAction - что делать с ревью, которое ждёт дольше SLA команды.
+type SLAAction string
+
+const (
+	// SLAActionEscalate пишет событие pr.review_overdue, один раз на ревью.
+	SLAActionEscalate SLAAction = "escalate"
+	// SLAActionReassign переназначает ревьювера, а если замены нет - эскалирует.
+	SLAActionReassign SLAAction = "reassign"
+)
+
+func (a SLAAction) IsValid() bool {
+	switch a {
+	case SLAActionEscalate, SLAActionReassign:
+		return true
+	default:
+		return false
+	}
+}
+
+// OverduePR - открытый PR с ревью, которые ждут дольше SLA команды автора.
+type OverduePR struct {
+	PullRequest *PullRequest
+	SLA         time.Duration
+	Reviews     []Review
+}
+
+// Escalation описывает просроченное ревью в событии pr.review_overdue.
+type Escalation struct {
+	ReviewerID string
+	AssignedAt time.Time
+	DueAt      time.Time
+}
+
+// SLAReport - итог одного прохода по просроченным ревью.
+type SLAReport struct {
+	Escalated  int
+	Reassigned int
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// StatisticsFilter - окно и команда, по которым считается статистика. Окно полуоткрытое,
+// [From, To), и применяется ко времени события: созданию PR для открытых, merge для
+// смерженных, назначению для назначений и переназначений.
+type StatisticsFilter struct {
+	From *time.Time
+	To   *time.Time
+	// команда автора PR
+	TeamName string
+}
+
+func (f StatisticsFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// CycleTime - распределение времени от создания PR до merge.
+type CycleTime struct {
+	Count   int
+	Average time.Duration
+	P50     time.Duration
+	P90     time.Duration
+}
+
+// NewCycleTime считает распределение длительностей так же, как percentile_cont в PostgreSQL:
+// перцентиль интерполируется линейно между соседними значениями.
+func NewCycleTime(durations []time.Duration) CycleTime {
+	if len(durations) == 0 {
+		return CycleTime{}
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return CycleTime{
+		Count:   len(sorted),
+		Average: total / time.Duration(len(sorted)),
+		P50:     percentile(sorted, 0.5),
+		P90:     percentile(sorted, 0.9),
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	fraction := position - float64(lower)
+	return sorted[lower] + time.Duration(math.Round(fraction*float64(sorted[lower+1]-sorted[lower])))
+}
+
+// PRStatistics - показатели PR группы: всех PR, команды или автора.
+type PRStatistics struct {
+	// PR в статусе OPEN, созданные в окне
+	OpenPRs int
+	// PR, смерженные в окне
+	MergedPRs   int
+	TimeToMerge CycleTime
+}
+
+type TeamStatistics struct {
+	TeamName string
+	PRStatistics
+}
+
+// UserStatistics - показатели пользователя как автора PR и как ревьювера.
+type UserStatistics struct {
+	UserID string
+	PRStatistics
+	// назначения ревьювером в окне по истории назначений, включая снятые позже
+	Assignments int
+	// сколько раз пользователя заменили другим ревьювером
+	Reassignments int
+}
+
+// WeeklyAssignments - число назначений за неделю, начинающуюся в понедельник WeekStart.
+type WeeklyAssignments struct {
+	WeekStart   time.Time
+	Assignments int
+}
+
+type Statistics struct {
+	PRStatistics
+	Teams              []TeamStatistics
+	Users              []UserStatistics
+	AssignmentsPerWeek []WeeklyAssignments
+	Assignments        int
+	// переназначения по причине; как и назначения, считаются по истории назначений
+	Reassignments map[AssignmentReason]int
+}
+
+// sort упорядочивает группы, чтобы ответ не зависел от хранилища.
+func (s *Statistics) sort() {
+	sort.Slice(s.Teams, func(i, j int) bool { return s.Teams[i].TeamName < s.Teams[j].TeamName })
+	sort.Slice(s.Users, func(i, j int) bool { return s.Users[i].UserID < s.Users[j].UserID })
+	sort.Slice(s.AssignmentsPerWeek, func(i, j int) bool {
+		return s.AssignmentsPerWeek[i].WeekStart.Before(s.AssignmentsPerWeek[j].WeekStart)
+	})
+}
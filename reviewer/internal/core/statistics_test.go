@@ -0,0 +1,32 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/core"
+)
+
+func TestNewCycleTime(t *testing.T) {
+	durations := []time.Duration{
+		7 * time.Minute, 1 * time.Minute, 10 * time.Minute, 4 * time.Minute, 2 * time.Minute,
+		9 * time.Minute, 3 * time.Minute, 6 * time.Minute, 8 * time.Minute, 5 * time.Minute,
+	}
+
+	// как percentile_cont: p50 между 5 и 6 минутами, p90 - 0.1 пути от 9 до 10
+	got := core.NewCycleTime(durations)
+	want := core.CycleTime{Count: 10, Average: 330 * time.Second, P50: 330 * time.Second, P90: 546 * time.Second}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if durations[0] != 7*time.Minute {
+		t.Error("expected input to stay unsorted")
+	}
+
+	if got := core.NewCycleTime([]time.Duration{time.Hour}); got.P50 != time.Hour || got.P90 != time.Hour {
+		t.Errorf("expected single value percentiles, got %+v", got)
+	}
+	if got := core.NewCycleTime(nil); got != (core.CycleTime{}) {
+		t.Errorf("expected empty cycle time, got %+v", got)
+	}
+}
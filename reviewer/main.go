@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
@@ -12,7 +13,12 @@ import (
 	"os/signal"
 
 	"pr-reviewer/internal/adapters/db"
+	"pr-reviewer/internal/adapters/memory"
+	"pr-reviewer/internal/adapters/metrics"
 	"pr-reviewer/internal/adapters/rest"
+	"pr-reviewer/internal/adapters/sla"
+	"pr-reviewer/internal/adapters/tracing"
+	"pr-reviewer/internal/adapters/webhook"
 	"pr-reviewer/internal/closers"
 	"pr-reviewer/internal/config"
 	"pr-reviewer/internal/core"
@@ -41,31 +47,163 @@ func run(cfg *config.Config, log *slog.Logger) error {
 	log.Info("starting server")
 	log.Debug("debug message are enabled")
 
-	// database adapter
-	storage, err := db.New(log, cfg.DBAddress)
+	storage, closer, err := openStorage(cfg, log)
 	if err != nil {
-		return fmt.Errorf("failed to connect to db: %v", err)
+		return err
 	}
 
-	defer closers.CloseOrLog(log, storage)
+	defer closers.CloseOrLog(log, closer)
 
-	if err := storage.Migrate(); err != nil {
-		return fmt.Errorf("failed to migrate db: %v", err)
+	tracingProvider, err := tracing.Setup(cfg.Tracing.Exporter, cfg.Tracing.ServiceName, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("invalid tracing config: %v", err)
 	}
+	defer closers.CloseOrLog(log, tracingProvider)
 
-	service := core.NewService(storage.Team, storage.User, storage.PR)
+	teamStrategies := make(map[string]core.SelectionStrategy, len(cfg.Reviewers.TeamStrategies))
+	for team, strategy := range cfg.Reviewers.TeamStrategies {
+		teamStrategies[team] = core.SelectionStrategy(strategy)
+	}
+	selector, err := core.NewStrategySelector(core.SelectionStrategy(cfg.Reviewers.DefaultStrategy), teamStrategies, storage.pr)
+	if err != nil {
+		return fmt.Errorf("invalid reviewers config: %v", err)
+	}
+
+	options := []core.Option{
+		core.WithReviewerSelector(selector),
+		core.WithTransactor(storage.tx),
+		core.WithExternalAccounts(storage.account),
+		core.WithAssignmentHistory(storage.assignment),
+		core.WithAudit(storage.audit),
+		core.WithTracer(tracing.NewTracer()),
+	}
+	if cfg.Webhooks.Enabled {
+		options = append(options, core.WithEvents(storage.event, storage.webhook))
+	}
+	if cfg.Auth.Enabled {
+		options = append(options, core.WithTokens(storage.token))
+	}
+	if cfg.Idempotency.Enabled {
+		if cfg.Idempotency.TTL <= 0 {
+			return fmt.Errorf("invalid idempotency config: ttl must be positive, got %s", cfg.Idempotency.TTL)
+		}
+		// иначе повтор ещё выполняющегося запроса выполнится второй раз
+		if cfg.Idempotency.Lease <= cfg.HTTPConfig.Timeout {
+			return fmt.Errorf("invalid idempotency config: lease must be longer than http timeout %s, got %s",
+				cfg.HTTPConfig.Timeout, cfg.Idempotency.Lease)
+		}
+		options = append(options, core.WithIdempotency(storage.idempotency, cfg.Idempotency.TTL, cfg.Idempotency.Lease))
+	}
+	var prometheus *metrics.Prometheus
+	if cfg.Metrics.Enabled {
+		prometheus = metrics.NewPrometheus()
+		options = append(options, core.WithMetrics(prometheus))
+	}
+	service := core.NewService(storage.team, storage.user, storage.pr, options...)
+
+	slaAction := core.SLAAction(cfg.SLA.Action)
+	if cfg.SLA.Enabled && !slaAction.IsValid() {
+		return fmt.Errorf("invalid sla config: unknown action %q", cfg.SLA.Action)
+	}
+	if cfg.SLA.Enabled && cfg.SLA.Interval <= 0 {
+		return fmt.Errorf("invalid sla config: interval must be positive, got %s", cfg.SLA.Interval)
+	}
+	if cfg.Webhooks.Enabled && cfg.Webhooks.PollInterval <= 0 {
+		return fmt.Errorf("invalid webhooks config: poll_interval must be positive, got %s", cfg.Webhooks.PollInterval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if cfg.Auth.Enabled {
+		if cfg.Auth.AdminToken != "" {
+			if err := service.EnsureToken(ctx, "admin token from config", core.RoleAdmin, cfg.Auth.AdminToken); err != nil {
+				return fmt.Errorf("invalid auth config: %v", err)
+			}
+		} else if err := requireAdminToken(ctx, service); err != nil {
+			return fmt.Errorf("invalid auth config: %v", err)
+		}
+	} else {
+		log.Info("authentication is disabled: API is open to everyone")
+	}
+
+	if cfg.Webhooks.Enabled {
+		dispatcher := webhook.NewDispatcher(log, storage.webhook, &http.Client{
+			Timeout:   cfg.Webhooks.Timeout,
+			Transport: tracing.NewTransport(http.DefaultTransport),
+		}, webhook.Config{
+			PollInterval: cfg.Webhooks.PollInterval,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BaseBackoff:  cfg.Webhooks.BaseBackoff,
+			MaxBackoff:   cfg.Webhooks.MaxBackoff,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			Lease:        cfg.Webhooks.Lease,
+		})
+		go dispatcher.Run(ctx)
+	}
+
+	if cfg.SLA.Enabled {
+		go sla.NewScheduler(log, service, cfg.SLA.Interval, slaAction).Run(ctx)
+	}
 
 	mux := http.NewServeMux()
 	mux.Handle("POST /team/add", rest.CreateTeamHandler(log, service))
 	mux.Handle("GET /team/get", rest.GetTeamHandler(log, service))
+	mux.Handle("POST /team/updateSettings", rest.UpdateTeamSettingsHandler(log, service))
+	mux.Handle("POST /team/setCodeOwners", rest.SetCodeOwnersHandler(log, service))
+	mux.Handle("POST /team/deactivateUsers", rest.DeactivateTeamUsersHandler(log, service))
 	mux.Handle("POST /users/setIsActive", rest.SetUserActiveHandler(log, service))
+	mux.Handle("POST /users/setUnavailability", rest.SetUnavailabilityHandler(log, service))
 	mux.Handle("POST /pullRequest/create", rest.CreatePRHandler(log, service))
 	mux.Handle("POST /pullRequest/merge", rest.MergePRHandler(log, service))
 	mux.Handle("POST /pullRequest/reassign", rest.ReassignReviewerHandler(log, service))
+	mux.Handle("POST /pullRequest/review", rest.SubmitReviewHandler(log, service))
+	mux.Handle("POST /pullRequest/close", rest.ClosePRHandler(log, service))
+	mux.Handle("POST /pullRequest/reopen", rest.ReopenPRHandler(log, service))
+	mux.Handle("GET /pullRequest/get", rest.GetPRHandler(log, service))
+	mux.Handle("GET /pullRequest/list", rest.ListPRsHandler(log, service))
+	mux.Handle("GET /pullRequest/overdue", rest.GetOverduePRsHandler(log, service))
+	mux.Handle("GET /pullRequest/history", rest.GetPRHistoryHandler(log, service))
 	mux.Handle("GET /users/getReview", rest.GetUserReviewsHandler(log, service))
 	mux.Handle("GET /statistics", rest.GetStatisticsHandler(log, service))
+	mux.Handle("GET /audit", rest.GetAuditLogHandler(log, service))
+	mux.Handle("POST /webhooks/add", rest.CreateWebhookHandler(log, service))
+	mux.Handle("GET /webhooks/list", rest.ListWebhooksHandler(log, service))
+	mux.Handle("POST /webhooks/delete", rest.DeleteWebhookHandler(log, service))
+	mux.Handle("POST /integrations/accounts/link", rest.LinkExternalAccountHandler(log, service))
+	mux.Handle("POST /auth/tokens/add", rest.CreateTokenHandler(log, service))
+	mux.Handle("GET /auth/tokens/list", rest.ListTokensHandler(log, service))
+	mux.Handle("POST /auth/tokens/revoke", rest.RevokeTokenHandler(log, service))
+	if secret := cfg.Integrations.GitHub.WebhookSecret; secret != "" {
+		mux.Handle("POST /integrations/github/webhook", rest.GitHubWebhookHandler(log, service, secret))
+	} else {
+		log.Info("github integration is disabled: webhook secret is not set")
+	}
+	if token := cfg.Integrations.GitLab.WebhookToken; token != "" {
+		mux.Handle("POST /integrations/gitlab/webhook", rest.GitLabWebhookHandler(log, service, token))
+	} else {
+		log.Info("gitlab integration is disabled: webhook token is not set")
+	}
 
-	handler := rest.LoggingMiddleware(log)(mux)
+	if prometheus != nil {
+		mux.Handle("GET /metrics", prometheus.Handler())
+	}
+
+	var handler http.Handler = mux
+	if cfg.Idempotency.Enabled {
+		handler = rest.IdempotencyMiddleware(log, service)(handler)
+	}
+	if cfg.Auth.Enabled {
+		// вебхуки внешних систем проверяются своими секретами, метрики собирает Prometheus
+		handler = rest.AuthMiddleware(log, service,
+			"/metrics", "/integrations/github/webhook", "/integrations/gitlab/webhook")(handler)
+	}
+	// отказы аутентификации и идемпотентности тоже попадают в метрики и трассы
+	handler = rest.RouteMiddleware(mux)(rest.ActorMiddleware(handler))
+	if prometheus != nil {
+		handler = rest.MetricsMiddleware(prometheus)(handler)
+	}
+	handler = rest.LoggingMiddleware(log)(rest.TracingMiddleware(handler))
 
 	server := &http.Server{
 		Addr:         cfg.HTTPConfig.Address,
@@ -75,9 +213,6 @@ func run(cfg *config.Config, log *slog.Logger) error {
 		Handler:      handler,
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
-
 	go func() {
 		<-ctx.Done()
 		log.Debug("shutting down server")
@@ -95,6 +230,71 @@ func run(cfg *config.Config, log *slog.Logger) error {
 	return nil
 }
 
+// requireAdminToken не даёт запустить сервис с аутентификацией, но без действующего токена
+// администратора: без него нельзя выпустить ни одного токена, и API недоступен.
+func requireAdminToken(ctx context.Context, service *core.Service) error {
+	tokens, err := service.ListTokens(ctx)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if token.Role == core.RoleAdmin && !token.IsRevoked() {
+			return nil
+		}
+	}
+	return errors.New("admin token is not set and no active admin token exists: set AUTH_ADMIN_TOKEN or disable auth")
+}
+
+type stores struct {
+	team core.TeamStore
+	user core.UserStore
+	pr   core.PRStore
+	tx   core.Transactor
+
+	event   core.EventStore
+	webhook core.WebhookStore
+	account core.ExternalAccountStore
+
+	assignment core.AssignmentStore
+	token      core.TokenStore
+	audit      core.AuditStore
+
+	idempotency core.IdempotencyStore
+}
+
+func openStorage(cfg *config.Config, log *slog.Logger) (*stores, io.Closer, error) {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		log.Info("using in-memory storage")
+		storage := memory.New()
+		return &stores{
+			team: storage.Team, user: storage.User, pr: storage.PR, tx: storage,
+			event: storage.Event, webhook: storage.Webhook, account: storage.Account,
+			assignment: storage.Assignment, token: storage.Token, audit: storage.Audit,
+			idempotency: storage.Idempotency,
+		}, storage, nil
+	case config.StoragePostgres:
+		// database adapter
+		storage, err := db.New(log, cfg.DBAddress)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to db: %v", err)
+		}
+
+		if err := storage.Migrate(); err != nil {
+			closers.CloseOrLog(log, storage)
+			return nil, nil, fmt.Errorf("failed to migrate db: %v", err)
+		}
+		return &stores{
+			team: storage.Team, user: storage.User, pr: storage.PR, tx: storage,
+			event: storage.Event, webhook: storage.Webhook, account: storage.Account,
+			assignment: storage.Assignment, token: storage.Token, audit: storage.Audit,
+			idempotency: storage.Idempotency,
+		}, storage, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage: %s", cfg.Storage)
+	}
+}
+
 func mustSetupLogger(logLevel string) *slog.Logger {
 	var level slog.Level
 	switch logLevel {